@@ -0,0 +1,252 @@
+// Copyright © 2023-2024 Wei Shen <shenwei356@gmail.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package stable
+
+import (
+	"strings"
+
+	"github.com/mattn/go-runewidth"
+)
+
+// ANSI escape sequences, e.g., colors like "\x1b[32mPASS\x1b[0m",
+// are ignored when measuring cells, unless RawWidths() is called.
+
+// RawWidths makes ANSI escape sequences in cells counted in widths,
+// i.e., they are treated as normal characters.
+func (t *Table) RawWidths() *Table {
+	t.rawWidths = true
+	t.widthsChecked = false
+	return t
+}
+
+// WidthFunc sets the function for measuring the display width of text,
+// e.g., for glyphs of Nerd Fonts which are measured incorrectly by go-runewidth,
+// or for treating East Asian ambiguous characters as wide.
+// It is also called on single characters for wrapping and clipping cells.
+// The default is runewidth.StringWidth.
+func (t *Table) WidthFunc(f func(s string) int) *Table {
+	t.widthFunc = f
+	t.widthsChecked = false
+	return t
+}
+
+// EastAsianWidth sets whether East Asian ambiguous characters, e.g., ±, ①, and some Greek letters,
+// are treated as wide characters, which is the case in terminals configured for East Asian locales.
+// By default, it's determined by go-runewidth from the environment variables
+// RUNEWIDTH_EASTASIAN and LC_ALL, LC_CTYPE, or LANG.
+// It has no effect if WidthFunc is set.
+func (t *Table) EastAsianWidth(b bool) *Table {
+	t.condition = runewidth.NewCondition()
+	t.condition.EastAsianWidth = b
+	t.widthsChecked = false
+	return t
+}
+
+// cond returns the condition of go-runewidth in use.
+func (t *Table) cond() *runewidth.Condition {
+	if t.condition != nil {
+		return t.condition
+	}
+	return runewidth.DefaultCondition
+}
+
+// strWidth returns the display width of text, without handling ANSI escape sequences.
+func (t *Table) strWidth(s string) int {
+	if t.widthFunc != nil {
+		return t.widthFunc(s)
+	}
+	return t.cond().StringWidth(s)
+}
+
+// runeWidth returns the display width of a character.
+func (t *Table) runeWidth(r rune) int {
+	if t.widthFunc != nil {
+		return t.widthFunc(string(r))
+	}
+	return t.cond().RuneWidth(r)
+}
+
+// width returns the display width of a cell,
+// i.e., the width of the widest line for a multi-line cell,
+// unless newlines are replaced (see SingleLine).
+func (t *Table) width(s string) int {
+	if strings.IndexByte(s, '\n') >= 0 {
+		if t.joinLines() {
+			if j := t.joinCellLines(s); strings.IndexByte(j, '\n') < 0 {
+				return t.width(j)
+			}
+		}
+		var w int
+		for _, line := range strings.Split(s, "\n") {
+			w = max(w, t.width(line))
+		}
+		return w
+	}
+	if t.rawWidths || strings.IndexByte(s, '\x1b') < 0 {
+		return t.strWidth(s)
+	}
+	return t.strWidth(stripANSI(s))
+}
+
+// wrap wraps a cell, ANSI escape sequences are kept in the lines.
+// An error of wrapping is recorded and returned by Err().
+func (t *Table) wrap(lines []string, s string, maxWidth int, delimiters string, hyphen rune) []string {
+	var err error
+	if t.rawWidths || strings.IndexByte(s, '\x1b') < 0 {
+		lines, err = wrapTextFunc(lines, s, maxWidth, delimiters, hyphen, t.strWidth)
+	} else {
+		lines, err = wrapANSI(lines, s, maxWidth, delimiters, hyphen, t.strWidth)
+	}
+	if err != nil && t.err == nil {
+		t.err = err
+	}
+	return lines
+}
+
+// truncate clips a cell, ANSI escape sequences are kept.
+func (t *Table) truncate(s string, maxWidth int, tail string) string {
+	if t.widthFunc == nil && (t.rawWidths || strings.IndexByte(s, '\x1b') < 0) {
+		return t.cond().Truncate(s, maxWidth, tail)
+	}
+	if t.width(s) <= maxWidth {
+		return s
+	}
+	w := maxWidth - t.strWidth(tail)
+	if w < 1 {
+		return tail
+	}
+	return t.wrap(nil, s, w, "", 0)[0] + tail
+}
+
+// ansiLen returns the length of the ANSI escape sequence at the beginning of s,
+// 0 for none. CSI sequences (ESC [ ... final byte) and OSC sequences
+// (ESC ] ... BEL or ESC \) are supported.
+func ansiLen(s string) int {
+	if len(s) < 2 || s[0] != '\x1b' {
+		return 0
+	}
+	switch s[1] {
+	case '[':
+		for i := 2; i < len(s); i++ {
+			if s[i] >= 0x40 && s[i] <= 0x7e {
+				return i + 1
+			}
+		}
+	case ']':
+		for i := 2; i < len(s); i++ {
+			if s[i] == '\a' {
+				return i + 1
+			}
+			if s[i] == '\x1b' && i+1 < len(s) && s[i+1] == '\\' {
+				return i + 2
+			}
+		}
+	}
+	return 0
+}
+
+// stripANSI removes ANSI escape sequences.
+func stripANSI(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); {
+		if n := ansiLen(s[i:]); n > 0 {
+			i += n
+			continue
+		}
+		b.WriteByte(s[i])
+		i++
+	}
+	return b.String()
+}
+
+// ansiSeq is an escape sequence at the offset of the stripped text.
+type ansiSeq struct {
+	offset int
+	seq    string
+}
+
+const ansiReset = "\x1b[0m"
+
+// wrapANSI wraps a text containing ANSI escape sequences, which are ignored
+// in measuring widths. SGR sequences (colors and styles) active at the end of
+// a line are reset, and restored at the beginning of the next line,
+// so colors do not bleed into borders.
+func wrapANSI(lines []string, text string, maxWidth int, delimiters string, hyphen rune, strWidth func(string) int) ([]string, error) {
+	var plain strings.Builder
+	var seqs []ansiSeq
+	for i := 0; i < len(text); {
+		if n := ansiLen(text[i:]); n > 0 {
+			seqs = append(seqs, ansiSeq{offset: plain.Len(), seq: text[i : i+n]})
+			i += n
+			continue
+		}
+		plain.WriteByte(text[i])
+		i++
+	}
+
+	var active []string // SGR sequences not reset yet
+	var b strings.Builder
+	var start, end int
+	text = plain.String()
+	wrapped, err := wrapTextFunc(nil, text, maxWidth, delimiters, hyphen, strWidth)
+	for k, line := range wrapped {
+		b.Reset()
+		for _, seq := range active {
+			b.WriteString(seq)
+		}
+
+		// the hyphen is not a part of the text
+		var tail string
+		if !strings.HasPrefix(text[end:], line) {
+			tail = string(hyphen)
+			line = strings.TrimSuffix(line, tail)
+		}
+
+		start, end = end, end+len(line)
+		last := k == len(wrapped)-1
+		pos := start
+		for len(seqs) > 0 && (seqs[0].offset < end || last) {
+			b.WriteString(line[pos-start : seqs[0].offset-start])
+			pos = seqs[0].offset
+			b.WriteString(seqs[0].seq)
+			active = updateSGR(active, seqs[0].seq)
+			seqs = seqs[1:]
+		}
+		b.WriteString(line[pos-start:])
+		b.WriteString(tail)
+
+		if len(active) > 0 {
+			b.WriteString(ansiReset)
+		}
+		lines = append(lines, b.String())
+	}
+	return lines, err
+}
+
+// updateSGR updates the active SGR sequences with a new escape sequence.
+func updateSGR(active []string, seq string) []string {
+	if !strings.HasPrefix(seq, "\x1b[") || !strings.HasSuffix(seq, "m") { // not SGR
+		return active
+	}
+	if seq == ansiReset || seq == "\x1b[m" {
+		return active[:0]
+	}
+	return append(active, seq)
+}
@@ -0,0 +1,165 @@
+// Copyright © 2023-2024 Wei Shen <shenwei356@gmail.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package stable
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mattn/go-runewidth"
+)
+
+func TestANSI(t *testing.T) {
+	green := func(s string) string { return "\x1b[32m" + s + "\x1b[0m" }
+
+	tbl := New()
+	tbl.Header([]string{"test", "status"})
+	tbl.AddRow([]interface{}{"a", green("PASS")})
+	tbl.AddRow([]interface{}{"b", "FAIL"})
+	expect := `+------+--------+
+| test | status |
++======+========+
+| a    | ` + green("PASS") + `   |
++------+--------+
+| b    | FAIL   |
++------+--------+
+`
+	if out := string(tbl.Render(StyleGrid)); out != expect {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+
+	// each wrapped line terminates its color
+	tbl = New().MaxWidth(10)
+	tbl.Header([]string{"id", "text"})
+	tbl.AddRow([]interface{}{1, "ok " + green("all tests passed") + " done"})
+	out := string(tbl.Render(StyleGrid))
+	lines := strings.Split(strings.TrimSuffix(out, "\n"), "\n")
+	for _, line := range lines {
+		if w := runewidth.StringWidth(stripANSI(line)); w != runewidth.StringWidth(lines[0]) {
+			t.Errorf("unmatched line width: %q", line)
+		}
+		if strings.Contains(line, "\x1b[32m") && !strings.HasSuffix(strings.TrimRight(line, " |"), ansiReset) {
+			t.Errorf("color is not terminated: %q", line)
+		}
+	}
+	if !strings.Contains(out, "|    | \x1b[32mtests \x1b[0m     |") {
+		t.Errorf("color is not restored in the wrapped line:\n%s", out)
+	}
+
+	// clipping
+	tbl = New().MaxWidth(6).ClipCell("..")
+	tbl.Header([]string{"id", "text"})
+	tbl.AddRow([]interface{}{1, green("passed") + "!"})
+	if out := string(tbl.Render(StylePlain)); !strings.Contains(out, "\x1b[32mpass\x1b[0m..") {
+		t.Errorf("unexpected output:\n%q", out)
+	}
+
+	// raw widths
+	tbl = New().RawWidths()
+	tbl.Header([]string{"status"})
+	tbl.AddRow([]interface{}{green("PASS")})
+	if out := string(tbl.Render(StylePlain)); !strings.HasPrefix(out, "status     \n") {
+		t.Errorf("unexpected output:\n%q", out)
+	}
+}
+
+func TestWidthFunc(t *testing.T) {
+	width := func(s string) int {
+		var w int
+		for _, r := range s {
+			if r == 'X' {
+				w += 3
+			} else {
+				w += runewidth.RuneWidth(r)
+			}
+		}
+		return w
+	}
+
+	tbl := New().WidthFunc(width)
+	tbl.Header([]string{"id", "glyph"})
+	tbl.AddRow([]interface{}{1, "X"})
+	tbl.AddRow([]interface{}{2, "abc"})
+	expect := `+----+-------+
+| id | glyph |
++====+=======+
+| 1  | X   |
++----+-------+
+| 2  | abc   |
++----+-------+
+`
+	if got := string(tbl.Render(StyleGrid)); got != expect {
+		t.Errorf("expected:\n%s\ngot:\n%s", expect, got)
+	}
+
+	// wrapping and clipping
+	tbl = New().WidthFunc(width).MaxWidth(6)
+	tbl.Header([]string{"glyphs"})
+	tbl.AddRow([]interface{}{"XXXXX"})
+	expect = `+--------+
+| glyphs |
++========+
+| XX |
+| XX |
+| X    |
++--------+
+`
+	if got := string(tbl.Render(StyleGrid)); got != expect {
+		t.Errorf("expected:\n%s\ngot:\n%s", expect, got)
+	}
+
+	tbl = New().WidthFunc(width).MaxWidth(6).ClipCell("..")
+	tbl.Header([]string{"glyphs"})
+	tbl.AddRow([]interface{}{"XXXXX"})
+	if got := strings.Split(string(tbl.Render(StyleGrid)), "\n")[3]; got != "| X..  |" {
+		t.Errorf("unexpected clipped cell: %q", got)
+	}
+}
+
+func TestEastAsianWidth(t *testing.T) {
+	render := func(eastAsian bool) []string {
+		tbl := New().EastAsianWidth(eastAsian)
+		tbl.Header([]string{"symbol"})
+		tbl.AddRow([]interface{}{"±①αβγδ"})
+		return strings.Split(strings.TrimSuffix(string(tbl.Render(StyleGrid)), "\n"), "\n")
+	}
+
+	narrow, wide := render(false), render(true)
+	if got, expect := narrow[0], "+--------+"; got != expect {
+		t.Errorf("expected %s, got %s", expect, got)
+	}
+	if got, expect := wide[0], "+--------------+"; got != expect {
+		t.Errorf("expected %s, got %s", expect, got)
+	}
+
+	// all lines have the same width in each mode
+	for _, test := range []struct {
+		lines     []string
+		eastAsian bool
+	}{{narrow, false}, {wide, true}} {
+		cond := &runewidth.Condition{EastAsianWidth: test.eastAsian}
+		w := cond.StringWidth(test.lines[0])
+		for _, line := range test.lines {
+			if cond.StringWidth(line) != w {
+				t.Errorf("east asian width %v: misaligned line %q", test.eastAsian, line)
+			}
+		}
+	}
+}
@@ -0,0 +1,125 @@
+// Copyright © 2023-2024 Wei Shen <shenwei356@gmail.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package stable
+
+import (
+	"io"
+)
+
+// Methods in this file are chainable versions of the configuration methods
+// which return errors. The first error is recorded in the table, and the
+// following chainable calls are ignored. The error can be retrieved via Err(),
+// and it is also returned by RenderE() and FlushE().
+//
+//	tbl := stable.New().HeaderC([]string{"id", "name"}).AlignC(stable.AlignRight).MaxWidth(20)
+//	...
+//	data, err := tbl.RenderE(stable.StyleGrid)
+
+// Err returns the first error recorded by chainable methods,
+// by determining column widths in streaming mode like ErrTableTooWide,
+// or by writing data to the writer. Errors of Render() are returned by RenderE().
+// If there's none, ErrWidthsFrozen is returned for a width setter called
+// too late in streaming mode, which is only a warning and does not stop
+// chainable methods or flushing.
+func (t *Table) Err() error {
+	if t.err == nil {
+		return t.frozenErr
+	}
+	return t.err
+}
+
+// HeaderC is the chainable version of Header.
+func (t *Table) HeaderC(headers []string) *Table {
+	if t.err == nil {
+		_, t.err = t.Header(headers)
+	}
+	return t
+}
+
+// HeaderWithFormatC is the chainable version of HeaderWithFormat.
+func (t *Table) HeaderWithFormatC(headers []Column) *Table {
+	if t.err == nil {
+		_, t.err = t.HeaderWithFormat(headers)
+	}
+	return t
+}
+
+// AlignC is the chainable version of Align.
+func (t *Table) AlignC(align Align) *Table {
+	if t.err == nil {
+		_, t.err = t.Align(align)
+	}
+	return t
+}
+
+// VAlignC is the chainable version of VAlign.
+func (t *Table) VAlignC(valign VAlign) *Table {
+	if t.err == nil {
+		_, t.err = t.VAlign(valign)
+	}
+	return t
+}
+
+// WriterC is the chainable version of Writer.
+func (t *Table) WriterC(w io.Writer, bufRows uint) *Table {
+	if t.err == nil {
+		t.err = t.Writer(w, bufRows)
+	}
+	return t
+}
+
+// WithWriter is the same as WriterC, it sets a writer in a chain of calls.
+// Check the error of Writer() via Err(), or the one returned by FlushE().
+//
+//	tbl := stable.New().WithWriter(os.Stdout, 1024).MinWidth(10)
+func (t *Table) WithWriter(w io.Writer, bufRows uint) *Table {
+	return t.WriterC(w, bufRows)
+}
+
+// RenderE is similar to Render, but it returns the error recorded by
+// chainable methods, if there is, instead of rendering the table.
+// Errors of rendering like ErrTableTooWide are returned along with the output.
+func (t *Table) RenderE(style *TableStyle) ([]byte, error) {
+	if t.err != nil {
+		return nil, t.err
+	}
+	data, err := t.render(style)
+	if t.err != nil {
+		return data, t.err
+	}
+	return data, err
+}
+
+// FlushE is similar to Flush, but it returns the error recorded by
+// chainable methods, if there is, after flushing the data,
+// so the table is always completed, e.g., with the bottom line.
+// The first error of writing data is also returned,
+// and ErrAlreadyFlushed is returned if it's called again.
+// Nothing is flushed if the writer is not set.
+func (t *Table) FlushE() error {
+	var err error
+	if t.hasWriter {
+		err = t.flush()
+	}
+	if t.err != nil {
+		return t.err
+	}
+	return err
+}
@@ -0,0 +1,123 @@
+// Copyright © 2023-2024 Wei Shen <shenwei356@gmail.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package stable
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestChainable(t *testing.T) {
+	var buf bytes.Buffer
+	tbl := New().
+		HeaderC([]string{"id", "name"}).
+		AlignC(AlignRight).
+		VAlignC(VAlignMiddle).
+		WriterC(&buf, 0).
+		Style(StyleGrid).
+		MaxWidth(20)
+	if err := tbl.Err(); err != nil {
+		t.Fatal(err)
+	}
+	tbl.AddRowValues(1, "a")
+	if err := tbl.FlushE(); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "|  1 |    a |") {
+		t.Errorf("unexpected output:\n%s", buf.String())
+	}
+
+	// the first error is surfaced at the end
+	tbl = New().
+		HeaderC([]string{"id", "name"}).
+		AlignC(Align(100)).
+		VAlignC(VAlign(100)).
+		MaxWidth(20)
+	if tbl.Err() != ErrInvalidAlign {
+		t.Errorf("expected ErrInvalidAlign, got %v", tbl.Err())
+	}
+	if _, err := tbl.RenderE(StyleGrid); err != ErrInvalidAlign {
+		t.Errorf("expected ErrInvalidAlign, got %v", err)
+	}
+	if err := tbl.FlushE(); err != ErrInvalidAlign {
+		t.Errorf("expected ErrInvalidAlign, got %v", err)
+	}
+}
+
+func TestWithWriter(t *testing.T) {
+	var buf bytes.Buffer
+	tbl := New().HeaderC([]string{"id", "name"}).WithWriter(&buf, 1).Style(StyleGrid)
+	if !tbl.HasWriter() {
+		t.Errorf("the writer should be set")
+	}
+	tbl.AddRowValues(1, "a")
+	if err := tbl.FlushE(); err != nil {
+		t.Fatal(err)
+	}
+	tbl2 := New().HeaderC([]string{"id", "name"})
+	if tbl2.HasWriter() {
+		t.Errorf("the writer should not be set")
+	}
+	tbl2.AddRowValues(1, "a")
+	if expect := string(tbl2.Render(StyleGrid)); buf.String() != expect {
+		t.Errorf("unexpected output:\n%s", buf.String())
+	}
+
+	// the error of setting the writer repeatedly is sticky
+	tbl = New().WithWriter(&buf, 1).WithWriter(&buf, 1).MaxWidth(20)
+	if tbl.Err() != ErrWriterRepeatedlySet {
+		t.Errorf("expected ErrWriterRepeatedlySet, got %v", tbl.Err())
+	}
+	if err := tbl.FlushE(); err != ErrWriterRepeatedlySet {
+		t.Errorf("expected ErrWriterRepeatedlySet, got %v", err)
+	}
+}
+
+func TestFlushEWithWarning(t *testing.T) {
+	var buf bytes.Buffer
+	tbl := New().HeaderC([]string{"id"}).WithWriter(&buf, 1).Style(StyleGrid)
+	tbl.AddRowValues(1)
+	tbl.AddRowValues(2) // dumping
+	tbl.MaxWidth(10)    // too late, only a warning
+	if !errors.Is(tbl.Err(), ErrWidthsFrozen) {
+		t.Errorf("expected ErrWidthsFrozen, got %v", tbl.Err())
+	}
+	if err := tbl.FlushE(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if expect := "+----+\n| id |\n+====+\n| 1  |\n+----+\n| 2  |\n+----+\n"; buf.String() != expect {
+		t.Errorf("unexpected output:\n%s", buf.String())
+	}
+
+	// the table is completed before the error is reported
+	buf.Reset()
+	tbl = New().HeaderC([]string{"id"}).WithWriter(&buf, 1).Style(StyleGrid)
+	tbl.AddRowValues(1)
+	tbl.AddRowValues(2)
+	tbl.AlignC(Align(100))
+	if err := tbl.FlushE(); err != ErrInvalidAlign {
+		t.Errorf("expected ErrInvalidAlign, got %v", err)
+	}
+	if !strings.HasSuffix(buf.String(), "| 2  |\n+----+\n") {
+		t.Errorf("the table is not completed:\n%s", buf.String())
+	}
+}
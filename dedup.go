@@ -0,0 +1,163 @@
+// Copyright © 2023-2024 Wei Shen <shenwei356@gmail.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package stable
+
+// DedupConsecutive collapses runs of identical consecutive rows into one row,
+// with a column showing the repeat count. If a column with the given header
+// exists, the count is written into it (and its values are ignored in
+// comparison), otherwise a right-aligned column is appended.
+// The count column participates in width computation and HumanizeNumbers.
+// In memory mode, a row is added immediately, and its count is updated in place
+// by identical rows after it, while markers (like sections) and editing rows end the run.
+// In streaming mode, a row is held back until a different row arrives or
+// Flush() is called.
+// It should be called before adding any rows.
+func (t *Table) DedupConsecutive(counterHeader string) *Table {
+	if t.dataAdded {
+		return t
+	}
+	t.dedup = true
+	t.dedupHeader = counterHeader
+	return t
+}
+
+// setupDedup determines the count column, n is the length of the first row.
+func (t *Table) setupDedup(n int) {
+	t.dedupReady = true
+
+	if t.hasHeader {
+		if i, err := t.columnIndex(t.dedupHeader); err == nil {
+			t.dedupCol = i
+			return
+		}
+	}
+
+	t.dedupAppend = true
+	if t.columns == nil { // no header, t.columns will be created by the first row
+		t.dedupCol = n
+		return
+	}
+	t.columns = append(t.columns, Column{Header: t.sanitizeCell(t.dedupHeader), Align: AlignRight})
+	t.nColumns++
+	t.widthsChecked = false
+	t.dedupCol = t.nColumns - 1
+}
+
+// addRowDedup adds a row, identical consecutive rows are collapsed.
+func (t *Table) addRowDedup(row []interface{}) error {
+	if !t.dedupReady {
+		t.setupDedup(len(row))
+	}
+	if t.dedupAppend {
+		row = append(row[:len(row):len(row)], 0) // a placeholder of the count
+	}
+
+	_row, err := t.checkRow(row)
+	if err != nil {
+		return err
+	}
+	if !t.hasWriter {
+		return t.addRowDedupInMemory(_row, row)
+	}
+
+	if t.pending != nil && t.sameRow(_row, t.pending) {
+		t.pendingCount++
+		return nil
+	}
+
+	if err = t.commitPending(); err != nil {
+		return err
+	}
+	t.pending = _row
+	t.pendingCount = 1
+	if t.keepRaw {
+		t.pendingRaw = t.rawRow(row)
+	}
+	return nil
+}
+
+// addRowDedupInMemory adds a parsed row in memory mode,
+// where the count of the last row is updated in place for an identical row.
+func (t *Table) addRowDedupInMemory(_row []string, row []interface{}) error {
+	n := len(t.rows)
+	count := 1
+	if t.runCount > 0 && n > 0 && t.sameRow(_row, t.rows[n-1]) {
+		count = t.runCount + 1
+	}
+	s, err := t.convertToString(count, t.dedupCol)
+	if err != nil {
+		return err
+	}
+
+	if count > 1 {
+		t.rows[n-1][t.dedupCol] = s
+		if t.keepRaw {
+			t.rawRows[n-1][t.dedupCol] = count
+		}
+		t.runCount = count
+		t.widthsChecked = false
+		return nil
+	}
+
+	_row[t.dedupCol] = s
+	if t.keepRaw {
+		raw := t.rawRow(row)
+		raw[t.dedupCol] = count
+		t.rawRows = append(t.rawRows, raw)
+	}
+	if err = t.addRow(_row); err != nil {
+		return err
+	}
+	t.runCount = count
+	return nil
+}
+
+// sameRow tells if the two rows are identical, the count column is ignored.
+func (t *Table) sameRow(a, b []string) bool {
+	for i, v := range a {
+		if i != t.dedupCol && v != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// commitPending adds the held-back row with the repeat count.
+// The run of the last row in memory mode is also ended.
+func (t *Table) commitPending() error {
+	t.runCount = 0
+	if t.pending == nil {
+		return nil
+	}
+	row := t.pending
+	t.pending = nil
+
+	var err error
+	row[t.dedupCol], err = t.convertToString(t.pendingCount, t.dedupCol)
+	if err != nil {
+		return err
+	}
+	if t.keepRaw {
+		t.pendingRaw[t.dedupCol] = t.pendingCount
+		t.rawRows = append(t.rawRows, t.pendingRaw)
+		t.pendingRaw = nil
+	}
+	return t.addRow(row)
+}
@@ -0,0 +1,125 @@
+// Copyright © 2023-2024 Wei Shen <shenwei356@gmail.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package stable
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDedupConsecutive(t *testing.T) {
+	fields := func(data []byte) []string {
+		lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+		s := make([]string, len(lines))
+		for i, line := range lines {
+			s[i] = strings.Join(strings.Fields(line), " ")
+		}
+		return s
+	}
+	rows := [][]interface{}{
+		{"a", 1}, {"a", 1}, {"b", 2}, {"a", 1}, {"c", 3}, {"c", 3}, {"c", 3},
+	}
+	expected := []string{"name value count", "a 1 2", "b 2 1", "a 1 1", "c 3 3"}
+
+	// in memory
+	tbl := New().DedupConsecutive("count")
+	tbl.Header([]string{"name", "value"})
+	for _, row := range rows {
+		if err := tbl.AddRow(row); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := tbl.NumRows(); n != 4 {
+		t.Errorf("unexpected number of rows: %d", n)
+	}
+	if got := fields(tbl.Render(StylePlain)); strings.Join(got, "|") != strings.Join(expected, "|") {
+		t.Errorf("unexpected output:\n%s", strings.Join(got, "\n"))
+	}
+
+	// the last run is a row of the table in memory mode
+	if expect := [][]string{{"a", "1", "2"}, {"b", "2", "1"}, {"a", "1", "1"}, {"c", "3", "3"}}; !reflect.DeepEqual(tbl.Rows(), expect) {
+		t.Errorf("unexpected rows: %v", tbl.Rows())
+	}
+	if err := tbl.UpdateCell(3, 0, "d"); err != nil {
+		t.Error(err)
+	}
+	tbl.AddRow([]interface{}{"d", 3}) // editing rows ends the run
+	if err := tbl.RemoveRow(4); err != nil {
+		t.Error(err)
+	}
+
+	// markers end the run
+	tbl = New().DedupConsecutive("count")
+	tbl.Header([]string{"name"})
+	tbl.AddRow([]interface{}{"a"})
+	tbl.AddSeparator()
+	tbl.AddRow([]interface{}{"a"})
+	tbl.AddRow([]interface{}{"a"})
+	if expect := [][]string{{"a", "1"}, {"a", "2"}}; !reflect.DeepEqual(tbl.Rows(), expect) {
+		t.Errorf("unexpected rows: %v", tbl.Rows())
+	}
+
+		// streaming, the held-back row is written by Flush
+	var buf bytes.Buffer
+	tbl = New().DedupConsecutive("count")
+	tbl.Writer(&buf, 1)
+	tbl.Style(StylePlain)
+	tbl.Header([]string{"name", "value"})
+	for _, row := range rows {
+		tbl.AddRow(row)
+	}
+	tbl.Flush()
+	if got := fields(buf.Bytes()); strings.Join(got, "|") != strings.Join(expected, "|") {
+		t.Errorf("unexpected output in streaming mode:\n%s", buf.String())
+	}
+
+	// the held-back row is kept after detaching the writer
+	tbl = New().DedupConsecutive("count")
+	tbl.Writer(&buf, 10)
+	tbl.AddRow([]interface{}{"a"})
+	tbl.AddRow([]interface{}{"a"})
+	if err := tbl.DetachWriter(); err != nil {
+		t.Fatal(err)
+	}
+	tbl.AddRow([]interface{}{"a"})
+	if expect := [][]string{{"a", "3"}}; !reflect.DeepEqual(tbl.Rows(), expect) {
+		t.Errorf("unexpected rows after detaching the writer: %v", tbl.Rows())
+	}
+
+		// an existing column is reused
+	tbl = New().DedupConsecutive("n")
+	tbl.Header([]string{"name", "n"})
+	tbl.AddRow([]interface{}{"a", ""})
+	tbl.AddRow([]interface{}{"a", "x"})
+	tbl.AddRow([]interface{}{"b", ""})
+	if got := strings.Join(fields(tbl.Render(StylePlain)), "|"); got != "name n|a 2|b 1" {
+		t.Errorf("unexpected output: %s", got)
+	}
+
+	// no duplicates, no header
+	tbl = New().DedupConsecutive("count")
+	tbl.AddRow([]interface{}{"a"})
+	tbl.AddRow([]interface{}{"b"})
+	if got := strings.Join(fields(tbl.Render(StylePlain)), "|"); got != "a 1|b 1" {
+		t.Errorf("unexpected output: %s", got)
+	}
+}
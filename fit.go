@@ -0,0 +1,191 @@
+// Copyright © 2023-2024 Wei Shen <shenwei356@gmail.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package stable
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"golang.org/x/term"
+)
+
+// ErrTableTooWide means the minimum column widths exceed the maximum total width.
+var ErrTableTooWide = fmt.Errorf("stable: the minimum column widths exceed the maximum total width")
+
+// MaxTotalWidth limits the total width of the table, including borders,
+// separators and padding of the style. If the table is wider, the widest
+// columns are shrunk one by one, and their cells are wrapped (or clipped).
+// A column is not narrower than its MinWidth, the global MinWidth, or 1,
+// and its header with PreserveHeader(). Columns with NoShrink are not shrunk.
+// The chosen widths can be retrieved via ColumnWidths().
+// If these minimums alone exceed the limit, the table is rendered with them,
+// and ErrTableTooWide is returned by RenderE() for that rendering.
+// In streaming mode, it is recorded instead, which can be retrieved via Err() or FlushE().
+// In streaming mode, it applies when the buffered rows are dumped,
+// after which the setting is ignored and ErrWidthsFrozen is recorded.
+// 0 means no limit.
+func (t *Table) MaxTotalWidth(n int) *Table {
+	if t.widthsFrozen("MaxTotalWidth") {
+		return t
+	}
+	if n < 0 {
+		n = 0
+	}
+	t.maxTotalWidth = n
+	t.fitTerminal = false
+	return t
+}
+
+// FitTerminal limits the total width of the table to the width of the terminal,
+// i.e., the one of the writer if it is a terminal, or the standard output otherwise.
+// The environment variable COLUMNS is used if the size of the terminal is not available,
+// and there is no limit if the variable is not available either.
+// Please see MaxTotalWidth for details.
+func (t *Table) FitTerminal() *Table {
+	if t.widthsFrozen("FitTerminal") {
+		return t
+	}
+	t.maxTotalWidth = 0
+	t.fitTerminal = true
+	return t
+}
+
+// terminalSize returns the width and height of the terminal of the file descriptor.
+var terminalSize = term.GetSize
+
+// totalWidthLimit returns the maximum total width, 0 for no limit.
+func (t *Table) totalWidthLimit() int {
+	if !t.fitTerminal {
+		return t.maxTotalWidth
+	}
+	fd := int(os.Stdout.Fd())
+	if f, ok := t.writer.(interface{ Fd() uintptr }); ok && t.hasWriter {
+		fd = int(f.Fd())
+	}
+	n, _, err := terminalSize(fd)
+	if err != nil || n <= 0 {
+		n, err = strconv.Atoi(os.Getenv("COLUMNS"))
+		if err != nil || n <= 0 {
+			return 0
+		}
+	}
+	return max(n-t.strWidth(t.linePrefix), 1) // the line prefix takes up the terminal width
+}
+
+// ColumnWidths returns the widths of columns (the row-number column is not included)
+// for rendering with the given style, i.e., the widths after fitting the
+// maximum total width (see MaxTotalWidth), and ErrTableTooWide is returned
+// if it can not be satisfied.
+func (t *Table) ColumnWidths(style *TableStyle) ([]int, error) {
+	if style == nil { // the argument not given
+		style = t.style
+	}
+	if style == nil { // not defined in the object
+		style = StyleGrid
+	}
+
+	if !t.widthsChecked && !t.bufRowsDumped {
+		t.checkWidths()
+	}
+	err := t.fitWidths(style)
+
+	widths := make([]int, len(t.columns))
+	copy(widths, t.maxWidths[len(t.maxWidths)-len(t.columns):])
+	return widths, err
+}
+
+// TotalWidth returns the display width of the table rendered with the given style,
+// without rendering it, e.g., for centering the table. The line prefix (see LinePrefix)
+// is not included, and ErrTableTooWide is returned if the maximum total width
+// can not be satisfied. In streaming mode, it's determined by the buffered rows
+// before they are written.
+func (t *Table) TotalWidth(style *TableStyle) (int, error) {
+	if style == nil { // the argument not given
+		style = t.style
+	}
+	if style == nil { // not defined in the object
+		style = StyleGrid
+	}
+
+	if t.nColumns == 0 {
+		return 0, nil
+	}
+	if !t.widthsChecked && !t.bufRowsDumped {
+		t.checkWidths()
+	}
+	err := t.fitWidths(style)
+	return t.tableWidth(style), err
+}
+
+// fitWidths shrinks columns to fit the maximum total width,
+// it should be called after checkWidths.
+// The widths are fitted from the ones determined by checkWidths, so it can be called
+// for another style or limit, while they are never changed after the buffered rows
+// are written in streaming mode.
+func (t *Table) fitWidths(style *TableStyle) error {
+	if t.bufRowsDumped { // the widths are frozen
+		return nil
+	}
+	copy(t.maxWidths, t.natWidths)
+
+	limit := t.totalWidthLimit()
+	if limit <= 0 {
+		return nil
+	}
+	excess := t.tableWidth(style) - limit
+	if excess <= 0 {
+		return nil
+	}
+
+	// minimum widths
+	offset := len(t.maxWidths) - len(t.columns) // the row-number column
+	floors := make([]int, len(t.maxWidths))
+	for i := range floors {
+		if i < offset { // the row-number column is not shrunk
+			floors[i] = t.maxWidths[i]
+			continue
+		}
+		if t.columns[i-offset].NoShrink {
+			floors[i] = t.maxWidths[i]
+			continue
+		}
+		floors[i] = max(1, max(t.minWidth, t.columns[i-offset].MinWidth))
+		if t.preserveHeader && t.hasHeader {
+			floors[i] = max(floors[i], t.width(t.columns[i-offset].Header))
+		}
+	}
+
+	// shrink the widest column each time
+	var j, w int
+	for ; excess > 0; excess-- {
+		j, w = -1, 0
+		for i, M := range t.maxWidths {
+			if M > floors[i] && M > w {
+				j, w = i, M
+			}
+		}
+		if j < 0 {
+			return fmt.Errorf("%w: %d", ErrTableTooWide, limit)
+		}
+		t.maxWidths[j]--
+	}
+	return nil
+}
@@ -0,0 +1,195 @@
+// Copyright © 2023-2024 Wei Shen <shenwei356@gmail.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package stable
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/mattn/go-runewidth"
+)
+
+func TestMaxTotalWidth(t *testing.T) {
+	newTable := func() *Table {
+		tbl := New().HumanizeNumbers().MaxWidth(40)
+		tbl.Header([]string{"id", "name", "sentence"})
+		tbl.AddRow([]interface{}{100, "Donec Vitae", "Quis autem vel eum iure reprehenderit qui in ea voluptate velit esse."})
+		tbl.AddRow([]interface{}{2000, "Quaerat Voluptatem", "At vero eos et accusamus et iusto odio."})
+		tbl.AddRow([]interface{}{3000000, "Aliquam lorem", "Curabitur ullamcorper ultricies nisi. Nam eget dui. Etiam rhoncus. Maecenas tempus, tellus eget condimentum rhoncus, sem quam semper libero."})
+		return tbl
+	}
+	checkWidth := func(out []byte, width int) {
+		lines := strings.Split(strings.TrimSuffix(string(out), "\n"), "\n")
+		if w := runewidth.StringWidth(lines[0]); w != width {
+			t.Errorf("unexpected table width: %d", w)
+		}
+		for _, line := range lines {
+			if w := runewidth.StringWidth(line); w > width {
+				t.Errorf("line too wide: %q", line)
+			}
+		}
+	}
+
+	for _, style := range []*TableStyle{StyleGrid, StyleLight, StylePlain} {
+		tbl := newTable().MaxTotalWidth(60)
+		checkWidth(tbl.Render(style), 60)
+		if tbl.Err() != nil {
+			t.Error(tbl.Err())
+		}
+	}
+
+	// the minimum widths exceed the budget
+	tbl := newTable().MinWidth(25).MaxTotalWidth(60)
+	if _, err := tbl.RenderE(StyleGrid); !errors.Is(err, ErrTableTooWide) {
+		t.Errorf("expected ErrTableTooWide, got %v", err)
+	}
+	// the error is only for the rendering
+	if out, err := tbl.RenderE(StyleGrid); !errors.Is(err, ErrTableTooWide) || len(out) == 0 {
+		t.Errorf("expected ErrTableTooWide with the output, got %v", err)
+	}
+	if tbl.Err() != nil {
+		t.Errorf("unexpected sticky error: %v", tbl.Err())
+	}
+
+	_terminalSize := terminalSize
+	defer func() { terminalSize = _terminalSize }()
+
+	// the terminal width
+	terminalSize = func(fd int) (int, int, error) { return 45, 24, nil }
+	t.Setenv("COLUMNS", "50")
+	checkWidth(newTable().FitTerminal().Render(StyleGrid), 45)
+
+	// the environment variable COLUMNS if the size is not available
+	terminalSize = func(fd int) (int, int, error) { return 0, 0, errors.New("not a terminal") }
+	checkWidth(newTable().FitTerminal().Render(StyleGrid), 50)
+}
+
+func TestColumnWidths(t *testing.T) {
+	newTable := func(budget int) *Table {
+		tbl := New().MaxTotalWidth(budget)
+		tbl.HeaderWithFormat([]Column{
+			{Header: "id", NoShrink: true},
+			{Header: "name", MinWidth: 6},
+			{Header: "description"},
+		})
+		tbl.AddRow([]interface{}{"sample_001", "Escherichia coli", "isolated from a stool sample of a healthy adult"})
+		return tbl
+	}
+
+	// the natural widths are 10, 16, 47, and the overhead of grid style is 10.
+	tests := []struct {
+		budget int
+		widths []int
+		err    error
+	}{
+		{100, []int{10, 16, 47}, nil},          // generous
+		{83, []int{10, 16, 47}, nil},           // exact
+		{60, []int{10, 16, 24}, nil},           // the widest column shrinks first
+		{40, []int{10, 10, 10}, nil},           // tight
+		{25, []int{10, 6, 1}, ErrTableTooWide}, // the floors can not fit
+		{20, []int{10, 6, 1}, ErrTableTooWide}, // impossible
+	}
+
+	for _, test := range tests {
+		widths, err := newTable(test.budget).ColumnWidths(StyleGrid)
+		if !errors.Is(err, test.err) {
+			t.Errorf("budget %d: expected error %v, got %v", test.budget, test.err, err)
+		}
+		if !reflect.DeepEqual(widths, test.widths) {
+			t.Errorf("budget %d: expected widths %v, got %v", test.budget, test.widths, widths)
+		}
+	}
+}
+
+func TestTotalWidth(t *testing.T) {
+	newTable := func() *Table {
+		tbl := New().MaxWidth(10)
+		tbl.Header([]string{"id", "name", "description"})
+		tbl.AddRow([]interface{}{1, "沈伟", "a long description"})
+		tbl.AddRow([]interface{}{2, "alpha", "short"})
+		return tbl
+	}
+
+	for _, style := range []*TableStyle{StylePlain, StyleSimple, StyleGrid, StyleLight, StyleBold, StyleDouble} {
+		tbl := newTable()
+		w, err := tbl.TotalWidth(style)
+		if err != nil {
+			t.Fatal(err)
+		}
+		out := string(tbl.Render(style))
+		if line := out[:strings.IndexByte(out, '\n')]; runewidth.StringWidth(line) != w {
+			t.Errorf("style %s: expected %d, got %d", style.Name, runewidth.StringWidth(line), w)
+		}
+	}
+
+	if w, err := New().TotalWidth(nil); w != 0 || err != nil {
+		t.Errorf("unexpected width of an empty table: %d, %v", w, err)
+	}
+	if _, err := newTable().MaxTotalWidth(10).TotalWidth(StyleGrid); !errors.Is(err, ErrTableTooWide) {
+		t.Errorf("expected ErrTableTooWide, got %v", err)
+	}
+
+	// streaming
+	var buf bytes.Buffer
+	tbl := New()
+	tbl.Writer(&buf, 2)
+	tbl.Header([]string{"id", "name"})
+	tbl.AddRow([]interface{}{1, "alpha"})
+	tbl.AddRow([]interface{}{2, "gamma delta"}) // widths are determined after this
+	w, _ := tbl.TotalWidth(nil)
+	tbl.AddRow([]interface{}{3, "a longer cell"})
+	tbl.Flush()
+	if line := buf.String()[:strings.IndexByte(buf.String(), '\n')]; runewidth.StringWidth(line) != w {
+		t.Errorf("streaming: expected %d, got %d", runewidth.StringWidth(line), w)
+	}
+}
+
+func TestFrozenWidths(t *testing.T) {
+	var buf bytes.Buffer
+	tbl := New().Style(StyleGrid).MaxTotalWidth(30)
+	tbl.Writer(&buf, 2)
+	tbl.Header([]string{"name", "value"})
+	tbl.Summary(map[string]Aggregate{"value": AggregateSum})
+	tbl.AddRow([]interface{}{"a fairly long name of a sample", 1})
+	tbl.AddRow([]interface{}{"b", 2}) // widths are determined after this
+
+	// probing widths after the buffered rows are written changes nothing
+	widths, _ := tbl.ColumnWidths(nil)
+	tbl.AddRow([]interface{}{"c", 3})
+	if w, _ := tbl.ColumnWidths(StyleGrid); !reflect.DeepEqual(w, widths) {
+		t.Errorf("widths changed after being frozen: %v -> %v", widths, w)
+	}
+	tbl.TotalWidth(nil)
+	tbl.AddRow([]interface{}{"d", 4})
+	tbl.Flush()
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	for _, line := range lines {
+		if w := runewidth.StringWidth(line); w != 30 {
+			t.Errorf("misaligned line of width %d: %q", w, line)
+		}
+	}
+	if got := strings.Fields(lines[len(lines)-2]); strings.Join(got, " ") != "| sum | 10 |" {
+		t.Errorf("unexpected summary row: %q", lines[len(lines)-2])
+	}
+}
@@ -5,6 +5,9 @@ go 1.17
 require (
 	github.com/dustin/go-humanize v1.0.1
 	github.com/mattn/go-runewidth v0.0.16
+	github.com/rivo/uniseg v0.4.7
+	golang.org/x/term v0.13.0
+	golang.org/x/text v0.13.0
 )
 
-require github.com/rivo/uniseg v0.4.7 // indirect
+require golang.org/x/sys v0.13.0 // indirect
@@ -0,0 +1,103 @@
+// Copyright © 2023-2024 Wei Shen <shenwei356@gmail.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package stable
+
+import "fmt"
+
+// ErrGroupByInStreamingMode means that grouping rows is not supported in streaming mode.
+var ErrGroupByInStreamingMode = fmt.Errorf("stable: grouping rows is not supported in streaming mode")
+
+// GroupBy groups rows by the values of the given column when rendering.
+// Rows are stably sorted by the column (see SortByIndex for the comparison),
+// each group starts with a section header of the value, and ends with
+// a subtotal row of the given columns, if there are. If subtotals are given,
+// a grand total row is also appended, like Summary(), which it replaces.
+// Markers like sections and separators added by users are dropped, as rows are reordered.
+// The rows of the table are left untouched.
+// It is not supported in streaming mode.
+func (t *Table) GroupBy(col string, subtotals map[string]Aggregate) error {
+	if t.hasWriter {
+		return ErrGroupByInStreamingMode
+	}
+	k, err := t.columnIndex(col)
+	if err != nil {
+		return err
+	}
+
+	var aggs []Aggregate
+	if len(subtotals) > 0 {
+		aggs = make([]Aggregate, t.nColumns)
+		for c, agg := range subtotals {
+			if agg < AggregateSum || agg > AggregateCount {
+				return ErrInvalidAggregate
+			}
+			i, err := t.columnIndex(c)
+			if err != nil {
+				return err
+			}
+			aggs[i] = agg
+		}
+	}
+
+	t.groupBy = true
+	t.groupCol = k
+	t.groupAggs = aggs
+	return nil
+}
+
+// renderGroups renders rows in groups.
+func (t *Table) renderGroups(style *TableStyle) ([]byte, error) {
+	rows := make([][]string, len(t.rows))
+	copy(rows, t.rows)
+	v := t.view(rows)
+	v.groupBy = false
+	v.keepRaw = false
+	v.SortByIndex(t.groupCol, SortAscending)
+
+	if t.groupAggs != nil {
+		v.summary = t.groupAggs
+		v.aggregators = make([]aggregator, v.nColumns)
+	}
+
+	v.markers = make(map[int][]marker)
+	k := t.groupCol
+	start := 0
+	for j := 0; j <= len(rows); j++ {
+		if j > 0 && (j == len(rows) || rows[j][k] != rows[j-1][k]) {
+			if t.groupAggs != nil { // subtotal of the previous group
+				v.resetSummary()
+				for _, row := range rows[start:j] {
+					v.accumulate(row)
+				}
+				v.summaryLabel = "subtotal"
+				v.markers[j] = append(v.markers[j], marker{cells: v.summaryRow()})
+			}
+			start = j
+		}
+		if j < len(rows) && (j == 0 || rows[j][k] != rows[j-1][k]) {
+			v.markers[j] = append(v.markers[j], marker{label: rows[j][k]})
+		}
+	}
+	if v.summary != nil {
+		v.summaryLabel = "total"
+	}
+
+	return v.render(style)
+}
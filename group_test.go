@@ -0,0 +1,73 @@
+// Copyright © 2023-2024 Wei Shen <shenwei356@gmail.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package stable
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestGroupBy(t *testing.T) {
+	tbl := New()
+	tbl.Header([]string{"sample", "group", "reads"})
+	tbl.AddRow([]interface{}{"s1", "case", 100})
+	tbl.AddRow([]interface{}{"s2", "control", 20})
+	tbl.AddRow([]interface{}{"s3", "case", 300})
+	tbl.AddRow([]interface{}{"s4", "control", 40})
+
+	if err := tbl.GroupBy("group", map[string]Aggregate{"reads": AggregateSum}); err != nil {
+		t.Fatal(err)
+	}
+
+	expect := `+----------+---------+-------+
+| sample   | group   | reads |
++==========+=========+=======+
+|            case            |
++----------+---------+-------+
+| s1       | case    | 100   |
++----------+---------+-------+
+| s3       | case    | 300   |
++----------+---------+-------+
+| subtotal |         | 400   |
++----------+---------+-------+
+|          control           |
++----------+---------+-------+
+| s2       | control | 20    |
++----------+---------+-------+
+| s4       | control | 40    |
++----------+---------+-------+
+| subtotal |         | 60    |
++==========+=========+=======+
+| total    |         | 460   |
++----------+---------+-------+
+`
+	if out := string(tbl.Render(StyleGrid)); out != expect {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+
+	// rows of the table are untouched
+	if got := column(tbl, 0); got[1] != "s2" {
+		t.Errorf("rows are reordered: %v", got)
+	}
+
+	if err := tbl.GroupBy("x", nil); !errors.Is(err, ErrColumnNotFound) {
+		t.Errorf("expected ErrColumnNotFound, got %v", err)
+	}
+}
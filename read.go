@@ -0,0 +1,133 @@
+// Copyright © 2023-2024 Wei Shen <shenwei356@gmail.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package stable
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// utf8BOM is the byte order mark which some tools put at the beginning of a UTF-8 file.
+const utf8BOM = "\ufeff"
+
+// FromCSV reads records from a CSV reader and adds them as rows.
+// If hasHeader is true, the first record is used as the header.
+// Records are added one by one via AddRow, so all options apply,
+// and in streaming mode (after calling Writer()) they are written incrementally.
+// Records with a different number of fields, or failing to be added,
+// cause an error with the line number.
+func (t *Table) FromCSV(r io.Reader, hasHeader bool) error {
+	reader := csv.NewReader(skipBOM(r))
+
+	var record []string
+	var err error
+	var n int
+	first := true
+	for {
+		record, err = reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err // a *csv.ParseError with the line number
+		}
+		n, _ = reader.FieldPos(0) // the line where the record starts
+
+		if first {
+			first = false
+
+			if hasHeader {
+				if _, err = t.Header(record); err != nil {
+					return fmt.Errorf("line %d: %w", n, err)
+				}
+				continue
+			}
+		}
+
+		if err = t.AddRowStringSlice(record); err != nil {
+			return fmt.Errorf("line %d: %w", n, err)
+		}
+	}
+
+	return nil
+}
+
+// skipBOM skips the byte order mark at the beginning of the reader, if there is,
+// so a quoted first field is still parsed as quoted.
+func skipBOM(r io.Reader) io.Reader {
+	br := bufio.NewReader(r)
+	if b, err := br.Peek(len(utf8BOM)); err == nil && string(b) == utf8BOM {
+		br.Discard(len(utf8BOM))
+	}
+	return br
+}
+
+// maxLineSize is the maximum size of a line for FromDelimited.
+const maxLineSize = 1 << 30
+
+// FromDelimited reads lines from a reader, splits them by sep and adds them as rows.
+// Unlike FromCSV, no quoting rules are applied, which fits the plain TSV
+// or "|"-separated output of many bioinformatics tools.
+// If hasHeader is true, the first non-blank line is used as the header.
+// Blank lines are skipped, and lines with an inconsistent number of fields,
+// or failing to be added, cause an error with the line number.
+func (t *Table) FromDelimited(r io.Reader, sep rune, hasHeader bool) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, bufio.MaxScanTokenSize), maxLineSize)
+
+	s := string(sep)
+	var line string
+	var record []string
+	var err error
+	var n int
+	first := true
+	for scanner.Scan() {
+		n++
+		line = scanner.Text()
+		if first {
+			line = strings.TrimPrefix(line, utf8BOM)
+		}
+		if line == "" {
+			continue
+		}
+
+		record = strings.Split(line, s)
+
+		if first {
+			first = false
+
+			if hasHeader {
+				if _, err = t.Header(record); err != nil {
+					return fmt.Errorf("line %d: %w", n, err)
+				}
+				continue
+			}
+		}
+
+		if err = t.AddRowStringSlice(record); err != nil {
+			return fmt.Errorf("line %d: %w", n, err)
+		}
+	}
+
+	return scanner.Err()
+}
@@ -0,0 +1,126 @@
+// Copyright © 2023-2024 Wei Shen <shenwei356@gmail.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package stable
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestFromCSV(t *testing.T) {
+	data := "\ufeffid,name,note\n" +
+		"1,Donec Vitae,\"multi\nline\"\n" +
+		"2,\"Quaerat, Voluptatem\",plain\n"
+
+	tbl := New()
+	if err := tbl.FromCSV(strings.NewReader(data), true); err != nil {
+		t.Fatal(err)
+	}
+	if !tbl.HasHeaders() || tbl.columns[0].Header != "id" {
+		t.Errorf("BOM not stripped from header: %q", tbl.columns[0].Header)
+	}
+	if len(tbl.rows) != 2 {
+		t.Fatalf("expected 2 rows, got %d", len(tbl.rows))
+	}
+	if tbl.rows[0][2] != "multi line" {
+		t.Errorf("unexpected multi-line cell: %q", tbl.rows[0][2])
+	}
+	if tbl.rows[1][1] != "Quaerat, Voluptatem" {
+		t.Errorf("unexpected quoted cell: %q", tbl.rows[1][1])
+	}
+
+	// a quoted first field after the BOM
+	tbl = New()
+	if err := tbl.FromCSV(strings.NewReader("\ufeff\"id\",name\n1,a\n"), true); err != nil {
+		t.Fatal(err)
+	}
+	if tbl.columns[0].Header != "id" {
+		t.Errorf("BOM not stripped from quoted header: %q", tbl.columns[0].Header)
+	}
+
+	// ragged records
+	tbl = New()
+	err := tbl.FromCSV(strings.NewReader("a,b\n1,2\n3\n"), true)
+	if err == nil || !strings.Contains(err.Error(), "line 3") {
+		t.Errorf("expected an error with line number, got %v", err)
+	}
+
+	// records failing to be added, the multi-line record starts at line 2
+	tbl = New().SummaryStrict()
+	tbl.Header([]string{"id", "value"})
+	tbl.Summary(map[string]Aggregate{"value": AggregateSum})
+	err = tbl.FromCSV(strings.NewReader("1,2\n2,\"n\n/a\"\n"), false)
+	if !errors.Is(err, ErrNonNumericCell) || !strings.HasPrefix(err.Error(), "line 2:") {
+		t.Errorf("expected an error with line number, got %v", err)
+	}
+
+	// streaming
+	var buf bytes.Buffer
+	tbl = New()
+	tbl.Writer(&buf, 1)
+	if err = tbl.FromCSV(strings.NewReader(data), true); err != nil {
+		t.Fatal(err)
+	}
+	tbl.Flush()
+	if !strings.Contains(buf.String(), "multi line") || !strings.Contains(buf.String(), "Voluptatem") {
+		t.Errorf("unexpected streaming output:\n%s", buf.String())
+	}
+}
+
+func TestFromDelimited(t *testing.T) {
+	tbl := New()
+	data := "id\tname\n\n1\tDonec Vitae\n2\tQuaerat Voluptatem\n"
+	if err := tbl.FromDelimited(strings.NewReader(data), '\t', true); err != nil {
+		t.Fatal(err)
+	}
+	if len(tbl.rows) != 2 {
+		t.Fatalf("expected 2 rows, got %d", len(tbl.rows))
+	}
+	if tbl.rows[1][1] != "Quaerat Voluptatem" {
+		t.Errorf("unexpected cell: %q", tbl.rows[1][1])
+	}
+
+	// a line longer than the default buffer of bufio.Scanner
+	long := strings.Repeat("x", 1<<20)
+	tbl = New()
+	if err := tbl.FromDelimited(strings.NewReader("a|"+long+"\n"), '|', false); err != nil {
+		t.Fatal(err)
+	}
+	if len(tbl.rows) != 1 || len(tbl.rows[0][1]) != 1<<20 {
+		t.Errorf("failed to read a long line")
+	}
+
+	// mismatched field counts
+	tbl = New()
+	err := tbl.FromDelimited(strings.NewReader("a|b\n1|2\n\n3\n"), '|', true)
+	if !errors.Is(err, ErrUnmatchedColumnNumber) || !strings.Contains(err.Error(), "line 4") {
+		t.Errorf("expected an error with line number, got %v", err)
+	}
+
+	// a header set after rows are added
+	tbl = New()
+	tbl.AddRow([]interface{}{1, 2})
+	err = tbl.FromDelimited(strings.NewReader("\na|b\n"), '|', true)
+	if !errors.Is(err, ErrSetHeaderAfterDataAdded) || !strings.HasPrefix(err.Error(), "line 2:") {
+		t.Errorf("expected an error with line number, got %v", err)
+	}
+}
@@ -0,0 +1,163 @@
+// Copyright © 2023-2024 Wei Shen <shenwei356@gmail.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package stable
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// SanitizeCells replaces C0 and C1 control characters in cells, which would
+// wreck the layout in terminals, e.g., "\r" and "\b". It also applies to other
+// given text, i.e., headers, section labels, footers, and the caption. Newlines and tabs are kept,
+// and so are ANSI escape sequences unless RawWidths() is called.
+// replace returns the replacement of a control character, if it is nil,
+// a visible escape like "\x0d" is used. Widths are computed with the replacements.
+// Note that it is applied after the conversion of characters (see Convert),
+// and DefaultConversionTable already replaces some of them, and "\r" is normalized
+// to a newline unless KeepCarriageReturns() is called.
+func (t *Table) SanitizeCells(replace func(r rune) string) *Table {
+	if replace == nil {
+		replace = escapeControl
+	}
+	t.sanitize = replace
+	t.cleanHeaders()
+	return t
+}
+
+// TrimCells removes leading and trailing Unicode whitespace of cells and headers,
+// before computing column widths. Note that the conversion of characters (see Convert) is applied before it,
+// which replaces tabs and newlines with spaces by default.
+func (t *Table) TrimCells() *Table {
+	t.trimCells = true
+	t.cleanHeaders()
+	return t
+}
+
+// CollapseWhitespace replaces runs of spaces and tabs in cells and headers with a single space,
+// before computing column widths.
+func (t *Table) CollapseWhitespace() *Table {
+	t.collapseSpaces = true
+	t.cleanHeaders()
+	return t
+}
+
+// NormalizeUnicode normalizes the text of cells and headers to the given form before computing
+// column widths, so decomposed characters like "e\u0301" are measured and compared
+// (see Column.MergeCells) the same as precomposed ones like "\u00e9". norm.NFC is recommended.
+// It is off by default.
+func (t *Table) NormalizeUnicode(form norm.Form) *Table {
+	t.normalize = true
+	t.normForm = form
+	t.cleanHeaders()
+	return t
+}
+
+// cleanCell normalizes, trims, and collapses whitespace of a cell if needed.
+func (t *Table) cleanCell(s string) string {
+	if t.normalize {
+		s = t.normForm.String(s)
+	}
+	if t.collapseSpaces {
+		s = collapseSpaces(s)
+	}
+	if t.trimCells {
+		s = strings.TrimSpace(s)
+	}
+	return s
+}
+
+// cleanHeaders normalizes, trims, collapses whitespace of, and sanitizes headers if needed,
+// the slice of columns is copied as it might be given by the user.
+func (t *Table) cleanHeaders() {
+	if (!t.trimCells && !t.collapseSpaces && !t.normalize && t.sanitize == nil) || t.columns == nil {
+		return
+	}
+	columns := make([]Column, len(t.columns))
+	copy(columns, t.columns)
+	for i := range columns {
+		columns[i].Header = t.sanitizeCell(t.cleanCell(columns[i].Header))
+	}
+	t.columns = columns
+	t.widthsChecked = false
+}
+
+// collapseSpaces replaces runs of spaces and tabs with a single space.
+func collapseSpaces(s string) string {
+	if !strings.Contains(s, "  ") && strings.IndexByte(s, '\t') < 0 {
+		return s
+	}
+	var b strings.Builder
+	b.Grow(len(s))
+	var inSpaces bool
+	for i := 0; i < len(s); i++ {
+		if s[i] == ' ' || s[i] == '\t' {
+			if !inSpaces {
+				b.WriteByte(' ')
+			}
+			inSpaces = true
+			continue
+		}
+		inSpaces = false
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+// escapeControl returns a visible escape of a control character.
+func escapeControl(r rune) string {
+	if r < 0x80 {
+		return fmt.Sprintf("\\x%02x", r)
+	}
+	return fmt.Sprintf("\\u%04x", r)
+}
+
+// isControl tells if r is a C0 or C1 control character, newlines and tabs are excluded.
+func isControl(r rune) bool {
+	return (r < 0x20 && r != '\n' && r != '\t') || (r >= 0x7f && r <= 0x9f)
+}
+
+// sanitizeCell replaces control characters in a cell.
+func (t *Table) sanitizeCell(s string) string {
+	if t.sanitize == nil || strings.IndexFunc(s, isControl) < 0 {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); {
+		if !t.rawWidths {
+			if n := ansiLen(s[i:]); n > 0 {
+				b.WriteString(s[i : i+n])
+				i += n
+				continue
+			}
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if isControl(r) {
+			b.WriteString(t.sanitize(r))
+		} else {
+			b.WriteString(s[i : i+size])
+		}
+		i += size
+	}
+	return b.String()
+}
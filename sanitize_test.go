@@ -0,0 +1,136 @@
+// Copyright © 2023-2024 Wei Shen <shenwei356@gmail.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package stable
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+func TestSanitizeCells(t *testing.T) {
+	tbl := New().Convert(nil).KeepCarriageReturns().SanitizeCells(nil)
+	tbl.Header([]string{"id", "text"})
+	tbl.AddRow([]interface{}{1, "abc\rdef\a"})
+	tbl.AddRowsFromSlices([][]string{{"2", "\x1b[32mok\x1b[0m\x00"}})
+	expect := `id   text          
+1    abc\x0ddef\x07
+2    ` + "\x1b[32mok\x1b[0m" + `\x00        
+`
+	if out := string(tbl.Render(StylePlain)); out != expect {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+
+	tbl = New().Convert(nil).KeepCarriageReturns().SanitizeCells(func(r rune) string { return "�" })
+	tbl.AddRow([]interface{}{"abc\rdef\a"})
+	if out := string(tbl.Render(StylePlain)); strings.TrimSpace(out) != "abc�def�" {
+		t.Errorf("unexpected output:\n%q", out)
+	}
+}
+
+func TestSanitizeOtherText(t *testing.T) {
+	tbl := New().Convert(nil).ShowRowNumbers("#\a").Caption("caption\x1b")
+	tbl.Header([]string{"id\b", "text"})
+	tbl.SanitizeCells(nil) // headers added before are also sanitized
+	tbl.AddSection("section\x00")
+	tbl.AddRow([]interface{}{1, "abc"})
+	tbl.Footer([]interface{}{"total\x7f", 1})
+	out := string(tbl.Render(StyleGrid))
+	if i := strings.IndexFunc(out, isControl); i >= 0 {
+		t.Errorf("unexpected control character %q in output:\n%s", out[i], out)
+	}
+	for _, s := range []string{`#\x07`, `id\x08`, `section\x00`, `total\x7f`, `caption\x1b`} {
+		if !strings.Contains(out, s) {
+			t.Errorf("%s not found in output:\n%s", s, out)
+		}
+	}
+}
+
+func TestTrimCellsAndCollapseWhitespace(t *testing.T) {
+	addRows := func(tbl *Table) *Table {
+		tbl.Header([]string{"  id ", "name\t\t"})
+		tbl.AddRow([]interface{}{"  1", "apple   pie  "})
+		tbl.AddRowStringSlice([]string{"2\t", "\tbanana \t split"})
+		return tbl
+	}
+
+	// tabs are converted to spaces by default
+	tbl := addRows(New())
+	if widths, _ := tbl.ColumnWidths(StylePlain); widths[0] != 5 || widths[1] != 15 {
+		t.Errorf("unexpected widths: %v", widths)
+	}
+
+	tbl = addRows(New().TrimCells())
+	if widths, _ := tbl.ColumnWidths(StylePlain); widths[0] != 2 || widths[1] != 14 {
+		t.Errorf("unexpected widths: %v", widths)
+	}
+
+	tbl = addRows(New().TrimCells().CollapseWhitespace())
+	expect := `id   name        
+1    apple pie   
+2    banana split
+`
+	if out := string(tbl.Render(StylePlain)); out != expect {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+
+	// options set after the header
+	headers := []Column{{Header: " a  b "}}
+	tbl = New()
+	tbl.HeaderWithFormat(headers)
+	tbl.CollapseWhitespace()
+	if tbl.columns[0].Header != " a b " || headers[0].Header != " a  b " {
+		t.Errorf("unexpected header: %q, the given one: %q", tbl.columns[0].Header, headers[0].Header)
+	}
+}
+
+func TestNormalizeUnicode(t *testing.T) {
+	precomposed, decomposed := "caf\u00e9", "cafe\u0301"
+	newTable := func(tbl *Table) *Table {
+		tbl.HeaderWithFormat([]Column{{Header: "id"}, {Header: "name", MergeCells: true}})
+		tbl.AddRow([]interface{}{1, precomposed})
+		tbl.AddRow([]interface{}{2, decomposed})
+		tbl.AddRow([]interface{}{3, "tea"})
+		tbl.AddRow([]interface{}{4, decomposed})
+		return tbl
+	}
+
+	tbl := newTable(New())
+	if rows := tbl.Rows(); rows[0][1] == rows[1][1] {
+		t.Errorf("cells should differ without normalization")
+	}
+
+	tbl = newTable(New().NormalizeUnicode(norm.NFC))
+	if rows := tbl.Rows(); rows[1][1] != precomposed || rows[3][1] != precomposed {
+		t.Errorf("cells are not normalized: %q", rows)
+	}
+	expect := "id   name\n" +
+		"1    caf\u00e9\n" +
+		"2        \n" +
+		"3    tea \n" +
+		"4    caf\u00e9\n"
+	if out := string(tbl.Render(StylePlain)); out != expect {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+	if widths, _ := tbl.ColumnWidths(StylePlain); widths[1] != 4 {
+		t.Errorf("unexpected widths: %v", widths)
+	}
+}
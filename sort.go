@@ -0,0 +1,251 @@
+// Copyright © 2023-2024 Wei Shen <shenwei356@gmail.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package stable
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// SortOrder is the order of sorting rows.
+type SortOrder int
+
+const (
+	SortAscending SortOrder = iota + 1
+	SortDescending
+)
+
+func (o SortOrder) String() string {
+	switch o {
+	case SortAscending:
+		return "ascending"
+	case SortDescending:
+		return "descending"
+	default:
+		return "unknown"
+	}
+}
+
+// ErrInvalidSortOrder means a invalid sort order is given.
+var ErrInvalidSortOrder = fmt.Errorf("stable: invalid sort order")
+
+// ErrColumnNotFound means the column with the given name does not exist.
+var ErrColumnNotFound = fmt.Errorf("stable: column not found")
+
+// ErrInvalidColumnIndex means the column index is out of range.
+var ErrInvalidColumnIndex = fmt.Errorf("stable: invalid column index")
+
+// ErrSortAfterRowsWritten means that sorting is not allowed
+// after some rows being written in streaming mode.
+var ErrSortAfterRowsWritten = fmt.Errorf("stable: sorting is not allowed after some rows being written")
+
+// SortIgnoreCase makes SortBy and SortByIndex compare strings case-insensitively.
+func (t *Table) SortIgnoreCase() *Table {
+	t.sortIgnoreCase = true
+	return t
+}
+
+// columnIndex returns the index of the column with the given header.
+func (t *Table) columnIndex(col string) (int, error) {
+	for i, c := range t.columns {
+		if c.Header == col {
+			return i, nil
+		}
+	}
+	return -1, fmt.Errorf("%w: %s", ErrColumnNotFound, col)
+}
+
+// SortBy sorts rows by the column with the given header.
+// Please see SortByIndex for details.
+func (t *Table) SortBy(col string, order SortOrder) error {
+	i, err := t.columnIndex(col)
+	if err != nil {
+		return err
+	}
+	return t.SortByIndex(i, order)
+}
+
+// SortByIndex sorts rows by the i-th (0-based) column.
+// If all cells of the column are numbers (see ParseNumeric, commas added by HumanizeNumbers are allowed),
+// they are compared numerically, otherwise they are compared as strings.
+// With KeepRawValues(), raw values are compared if they are all numeric.
+// The sorting is stable.
+// Markers like sections and separators move with the rows after them.
+// In streaming mode, it only sorts the buffered rows, and it returns an error
+// after these rows being written.
+func (t *Table) SortByIndex(i int, order SortOrder) error {
+	if t.bufRowsDumped || (t.hasWriter && t.flushed) {
+		return ErrSortAfterRowsWritten
+	}
+	if order != SortAscending && order != SortDescending {
+		return ErrInvalidSortOrder
+	}
+	if i < 0 || i >= t.nColumns {
+		return fmt.Errorf("%w: %d", ErrInvalidColumnIndex, i)
+	}
+
+	desc := order == SortDescending
+
+	idx := make([]int, len(t.rows))
+	for j := range idx {
+		idx[j] = j
+	}
+
+	// numeric
+	numbers, numeric := t.rawNumbers(i)
+	if !numeric {
+		numbers, numeric = t.numbers(i)
+	}
+	if numeric {
+		sort.SliceStable(idx, func(a, b int) bool {
+			if desc {
+				return numbers[idx[a]] > numbers[idx[b]]
+			}
+			return numbers[idx[a]] < numbers[idx[b]]
+		})
+		t.permute(idx)
+		return nil
+	}
+
+	// string
+	ignoreCase := t.sortIgnoreCase
+	sort.SliceStable(idx, func(a, b int) bool {
+		x, y := t.rows[idx[a]][i], t.rows[idx[b]][i]
+		if ignoreCase {
+			x, y = strings.ToLower(x), strings.ToLower(y)
+		}
+		if desc {
+			return x > y
+		}
+		return x < y
+	})
+	t.permute(idx)
+	return nil
+}
+
+// numbers parses cells of the i-th column as numbers,
+// it returns false if any cell is not a number.
+func (t *Table) numbers(i int) ([]float64, bool) {
+	numbers := make([]float64, len(t.rows))
+	var ok bool
+	for j, row := range t.rows {
+		numbers[j], ok = parseNumeric(row[i])
+		if !ok {
+			return nil, false
+		}
+	}
+	return numbers, true
+}
+
+// rawNumbers returns raw values of the i-th column as numbers,
+// it returns false if raw values are not kept or any of them is not numeric.
+func (t *Table) rawNumbers(i int) ([]float64, bool) {
+	if !t.keepRaw || len(t.rawRows) != len(t.rows) {
+		return nil, false
+	}
+	numbers := make([]float64, len(t.rawRows))
+	var ok bool
+	for j, row := range t.rawRows {
+		numbers[j], ok = rawNumber(row[i])
+		if !ok {
+			return nil, false
+		}
+	}
+	return numbers, true
+}
+
+// rawNumber converts a value of a numeric kind to float64,
+// named types like time.Duration are also supported.
+func rawNumber(v interface{}) (float64, bool) {
+	if v == nil {
+		return 0, false
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), true
+	default:
+		return 0, false
+	}
+}
+
+// permute reorders rows (and raw values) by the indexes,
+// markers like sections and separators are carried with the rows after them.
+func (t *Table) permute(idx []int) {
+	rows := make([][]string, len(t.rows))
+	for j, k := range idx {
+		rows[j] = t.rows[k]
+	}
+	copy(t.rows, rows)
+	t.runCount = 0
+
+	if len(t.markers) > 0 {
+		markers := make(map[int][]marker, len(t.markers))
+		for j, k := range idx {
+			if ms, ok := t.markers[k]; ok {
+				markers[j] = ms
+			}
+		}
+		if ms, ok := t.markers[len(t.rows)]; ok { // markers after the last row
+			markers[len(t.rows)] = ms
+		}
+		t.markers = markers
+		t.widthsChecked = false
+	}
+
+	if !t.keepRaw || len(t.rawRows) != len(t.rows) {
+		return
+	}
+	rawRows := make([][]interface{}, len(t.rawRows))
+	for j, k := range idx {
+		rawRows[j] = t.rawRows[k]
+	}
+	copy(t.rawRows, rawRows)
+}
+
+// SortFunc sorts rows with a custom less function over the cells,
+// which is useful for domain-specific orderings, e.g., "low < medium < high".
+// The sorting is stable. If the less function panics, the panic propagates
+// and the rows are left in their original order.
+// Markers like sections and separators move with the rows after them.
+// In streaming mode, it only sorts the buffered rows, and it returns an error
+// after these rows being written.
+func (t *Table) SortFunc(less func(a, b []string) bool) error {
+	if t.bufRowsDumped || (t.hasWriter && t.flushed) {
+		return ErrSortAfterRowsWritten
+	}
+
+	// sort indexes, so the rows are not touched if less panics.
+	idx := make([]int, len(t.rows))
+	for j := range idx {
+		idx[j] = j
+	}
+	sort.SliceStable(idx, func(a, b int) bool {
+		return less(t.rows[idx[a]], t.rows[idx[b]])
+	})
+	t.permute(idx)
+	return nil
+}
@@ -0,0 +1,202 @@
+// Copyright © 2023-2024 Wei Shen <shenwei356@gmail.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package stable
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func column(tbl *Table, i int) []string {
+	col := make([]string, len(tbl.rows))
+	for j, row := range tbl.rows {
+		col[j] = row[i]
+	}
+	return col
+}
+
+func TestSortBy(t *testing.T) {
+	tbl := New().HumanizeNumbers()
+	tbl.Header([]string{"id", "name", "mixed"})
+	tbl.AddRow([]interface{}{2000, "beta", "10"})
+	tbl.AddRow([]interface{}{100, "Alpha", "x"})
+	tbl.AddRow([]interface{}{3000000, "沈伟", "9"})
+	tbl.AddRow([]interface{}{100, "alpha", "1"})
+
+	tests := []struct {
+		col    string
+		order  SortOrder
+		index  int
+		expect []string
+	}{
+		{"id", SortAscending, 0, []string{"100", "100", "2,000", "3,000,000"}},
+		{"id", SortDescending, 0, []string{"3,000,000", "2,000", "100", "100"}},
+		{"name", SortAscending, 1, []string{"Alpha", "alpha", "beta", "沈伟"}},
+		{"name", SortDescending, 1, []string{"沈伟", "beta", "alpha", "Alpha"}},
+		{"mixed", SortAscending, 2, []string{"1", "10", "9", "x"}},
+	}
+	for _, test := range tests {
+		if err := tbl.SortBy(test.col, test.order); err != nil {
+			t.Fatal(err)
+		}
+		if got := column(tbl, test.index); !reflect.DeepEqual(got, test.expect) {
+			t.Errorf("sort by %s (%s): expected %v, got %v", test.col, test.order, test.expect, got)
+		}
+	}
+
+	// stable and case-insensitive
+	tbl.SortBy("id", SortAscending)
+	tbl.SortIgnoreCase().SortBy("name", SortAscending)
+	if got := column(tbl, 2); got[0] != "1" || got[1] != "x" {
+		t.Errorf("unstable case-insensitive sort: %v", got)
+	}
+
+	if err := tbl.SortBy("foo", SortAscending); !errors.Is(err, ErrColumnNotFound) {
+		t.Errorf("expected ErrColumnNotFound, got %v", err)
+	}
+	if err := tbl.SortByIndex(3, SortAscending); !errors.Is(err, ErrInvalidColumnIndex) {
+		t.Errorf("expected ErrInvalidColumnIndex, got %v", err)
+	}
+
+	// streaming
+	var buf bytes.Buffer
+	tbl = New()
+	tbl.Writer(&buf, 1)
+	tbl.Header([]string{"id"})
+	tbl.AddRow([]interface{}{2})
+	if err := tbl.SortBy("id", SortAscending); err != nil {
+		t.Errorf("sorting buffered rows should be allowed: %v", err)
+	}
+	tbl.AddRow([]interface{}{1})
+	if err := tbl.SortBy("id", SortAscending); err != ErrSortAfterRowsWritten {
+		t.Errorf("expected ErrSortAfterRowsWritten, got %v", err)
+	}
+}
+
+func TestSortFunc(t *testing.T) {
+	severity := map[string]int{"low": 0, "medium": 1, "high": 2}
+
+	tbl := New()
+	tbl.Header([]string{"job", "severity"})
+	tbl.AddRow([]interface{}{"a", "high"})
+	tbl.AddRow([]interface{}{"b", "low"})
+	tbl.AddRow([]interface{}{"c", "medium"})
+	tbl.AddRow([]interface{}{"d", "low"})
+
+	err := tbl.SortFunc(func(a, b []string) bool {
+		return severity[a[1]] < severity[b[1]]
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, expect := column(tbl, 0), []string{"b", "d", "c", "a"}; !reflect.DeepEqual(got, expect) {
+		t.Errorf("expected %v, got %v", expect, got)
+	}
+
+	// a panicking comparator
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Errorf("the panic should propagate")
+			}
+		}()
+		tbl.SortFunc(func(a, b []string) bool {
+			panic("boom")
+		})
+	}()
+	if got, expect := column(tbl, 0), []string{"b", "d", "c", "a"}; !reflect.DeepEqual(got, expect) {
+		t.Errorf("rows changed after a panic: %v", got)
+	}
+}
+
+func TestSortWithMarkers(t *testing.T) {
+	tbl := New()
+	tbl.Header([]string{"name", "value"})
+	tbl.AddRow([]interface{}{"a", 3})
+	tbl.AddSection("section")
+	tbl.AddRow([]interface{}{"b", 1})
+	tbl.AddRow([]interface{}{"c", 2})
+
+	if err := tbl.SortBy("value", SortAscending); err != nil {
+		t.Fatal(err)
+	}
+	expect := `name   value
+  section   
+b      1    
+c      2    
+a      3    
+`
+	if out := string(tbl.Render(StylePlain)); out != expect {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+}
+
+// size is a number shown with a unit, which can not be sorted as strings.
+type size int
+
+func (s size) String() string {
+	if s >= 1000 {
+		return fmt.Sprintf("%dK", s/1000)
+	}
+	return fmt.Sprintf("%dB", s)
+}
+
+func TestKeepRawValues(t *testing.T) {
+	tbl := New().HumanizeNumbers().KeepRawValues()
+	tbl.Header([]string{"file", "size", "reads"})
+	tbl.AddRow([]interface{}{"a", size(2000), 1500})
+	tbl.AddRow([]interface{}{"b", size(900), 20000})
+	tbl.AddRow([]interface{}{"c", size(10000), 300})
+
+	if got, expect := column(tbl, 2), []string{"1,500", "20,000", "300"}; !reflect.DeepEqual(got, expect) {
+		t.Errorf("expected %v, got %v", expect, got)
+	}
+	if got := tbl.RawRows()[1][2]; got != 20000 {
+		t.Errorf("unexpected raw value: %v", got)
+	}
+
+	if err := tbl.SortBy("size", SortAscending); err != nil {
+		t.Fatal(err)
+	}
+	if got, expect := column(tbl, 0), []string{"b", "a", "c"}; !reflect.DeepEqual(got, expect) {
+		t.Errorf("expected %v, got %v", expect, got)
+	}
+	if got := tbl.RawRows()[0][1]; got != size(900) {
+		t.Errorf("raw values are not sorted with rows: %v", got)
+	}
+
+	tbl.RemoveRow(0)
+	tbl.UpdateCell(0, 2, 7)
+	if got, expect := tbl.RawRows(), [][]interface{}{{"a", size(2000), 7}, {"c", size(10000), 300}}; !reflect.DeepEqual(got, expect) {
+		t.Errorf("expected %v, got %v", expect, got)
+	}
+
+	if raw := New().RawRows(); raw != nil {
+		t.Errorf("raw values should not be kept by default")
+	}
+
+	var buf bytes.Buffer
+	if err := New().KeepRawValues().Writer(&buf, 10); !errors.Is(err, ErrKeepRawValuesInStreamingMode) {
+		t.Errorf("expected ErrKeepRawValuesInStreamingMode, got %v", err)
+	}
+}
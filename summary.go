@@ -0,0 +1,302 @@
+// Copyright © 2023-2024 Wei Shen <shenwei356@gmail.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package stable
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+)
+
+// Aggregate is the type of aggregation in the summary row.
+type Aggregate int
+
+const (
+	AggregateSum Aggregate = iota + 1
+	AggregateMean
+	AggregateMin
+	AggregateMax
+	AggregateCount
+)
+
+func (a Aggregate) String() string {
+	switch a {
+	case AggregateSum:
+		return "sum"
+	case AggregateMean:
+		return "mean"
+	case AggregateMin:
+		return "min"
+	case AggregateMax:
+		return "max"
+	case AggregateCount:
+		return "count"
+	default:
+		return "unknown"
+	}
+}
+
+// ErrInvalidAggregate means a invalid aggregate value is given.
+var ErrInvalidAggregate = fmt.Errorf("stable: invalid aggregate value")
+
+// ErrNonNumericCell means a non-numeric cell is found in an aggregated column in strict mode.
+var ErrNonNumericCell = fmt.Errorf("stable: non-numeric cell in an aggregated column")
+
+// Summary appends a summary row computing the aggregates of the given columns,
+// keyed by the header name. Only cells which can be parsed as numbers
+// (see ParseNumeric, commas added by HumanizeNumbers are allowed) are counted, others are skipped
+// unless SummaryStrict() is called. The aggregated values are formatted with
+// the HumanizeNumbers option, and the first non-aggregated column shows
+// the name of the aggregate ("summary" for mixed aggregates).
+// In streaming mode, the aggregates are accumulated as rows being written,
+// and the summary row is written by Flush(). As the column widths are
+// determined by the buffered rows, an aggregate wider than its column is
+// clipped with the mark of ClipCell() ("…" by default).
+// It should be called after setting the header.
+func (t *Table) Summary(aggs map[string]Aggregate) (*Table, error) {
+	summary := make([]Aggregate, t.nColumns)
+	for col, agg := range aggs {
+		if agg < AggregateSum || agg > AggregateCount {
+			return nil, ErrInvalidAggregate
+		}
+		i, err := t.columnIndex(col)
+		if err != nil {
+			return nil, err
+		}
+		summary[i] = agg
+	}
+	t.summary = summary
+	t.aggregators = make([]aggregator, t.nColumns)
+	t.widthsChecked = false
+	return t, nil
+}
+
+// StreamSummary is the same as Summary, it is a more discoverable name for
+// streaming mode, where the aggregates are accumulated as each row passes
+// through AddRow, and the summary row is written by Flush() before the bottom line.
+func (t *Table) StreamSummary(cols map[string]Aggregate) error {
+	_, err := t.Summary(cols)
+	return err
+}
+
+// SummarySkipped returns the number of skipped non-numeric cells of each
+// aggregated column, keyed by the header name.
+func (t *Table) SummarySkipped() map[string]int {
+	m := make(map[string]int)
+	for i, agg := range t.summary {
+		if agg > 0 {
+			m[t.columns[i].Header] = t.aggregators[i].skipped
+		}
+	}
+	return m
+}
+
+// SummaryStrict makes AddRow return an error if a cell of an aggregated column
+// can not be parsed as a number.
+func (t *Table) SummaryStrict() *Table {
+	t.summaryStrict = true
+	return t
+}
+
+// aggregator accumulates numbers of a column.
+type aggregator struct {
+	n             int
+	sum, min, max float64
+	skipped       int // the number of non-numeric cells
+}
+
+func (a *aggregator) add(v float64) {
+	if a.n == 0 || v < a.min {
+		a.min = v
+	}
+	if a.n == 0 || v > a.max {
+		a.max = v
+	}
+	a.sum += v
+	a.n++
+}
+
+// checkSummary checks if aggregated cells are numeric in strict mode.
+func (t *Table) checkSummary(row []string) error {
+	if !t.summaryStrict || t.summary == nil {
+		return nil
+	}
+	for i, agg := range t.summary {
+		if agg == 0 {
+			continue
+		}
+		if _, ok := parseNumeric(row[i]); !ok {
+			return fmt.Errorf("%w: column %q: %q", ErrNonNumericCell, t.columns[i].Header, row[i])
+		}
+	}
+	return nil
+}
+
+// resetSummary clears the accumulated aggregates.
+func (t *Table) resetSummary() {
+	for i := range t.aggregators {
+		t.aggregators[i] = aggregator{}
+	}
+}
+
+// accumulate adds numeric cells of a row to the aggregates.
+func (t *Table) accumulate(row []string) {
+	for i, agg := range t.summary {
+		if agg == 0 {
+			continue
+		}
+		if v, ok := parseNumeric(row[i]); ok {
+			t.aggregators[i].add(v)
+		} else {
+			t.aggregators[i].skipped++
+		}
+	}
+}
+
+// summaryRow returns the formatted summary row.
+func (t *Table) summaryRow() []string {
+	row := make([]string, t.nColumns)
+
+	var label string
+	for i, agg := range t.summary {
+		if agg == 0 {
+			continue
+		}
+		if label == "" {
+			label = agg.String()
+		} else if label != agg.String() {
+			label = "summary"
+		}
+
+		a := t.aggregators[i]
+		var v float64
+		switch agg {
+		case AggregateSum:
+			v = a.sum
+		case AggregateMean:
+			if a.n > 0 {
+				v = a.sum / float64(a.n)
+			}
+		case AggregateMin:
+			v = a.min
+		case AggregateMax:
+			v = a.max
+		case AggregateCount:
+			v = float64(a.n)
+		}
+
+		var value interface{} = v
+		if v == math.Trunc(v) && math.Abs(v) < 1<<53 {
+			value = int64(v)
+		}
+		row[i], _ = t.convertToString(value, i)
+	}
+
+	if t.summaryLabel != "" {
+		label = t.sanitizeCell(t.summaryLabel)
+	}
+	for i, agg := range t.summary {
+		if agg == 0 {
+			row[i] = label
+			break
+		}
+	}
+	return row
+}
+
+// ErrFooterAfterFlush means Footer() is called after Flush() in streaming mode.
+var ErrFooterAfterFlush = fmt.Errorf("stable: calling Footer is not allowed after calling Flush()")
+
+// Footer sets a footer row, which is written below the data rows (and the summary row),
+// separated with the line below the header.
+// Cells are converted immediately, so errors are returned early.
+// In streaming mode, it can be called at any time before Flush(), which writes it.
+// If it is set after the buffered rows are written, cells wider than the determined widths
+// are wrapped or clipped like other rows.
+func (t *Table) Footer(row []interface{}) error {
+	if t.mu != nil {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+	}
+	if t.hasWriter && t.flushed {
+		return ErrFooterAfterFlush
+	}
+	if err := t.checkColumns(len(row), nil, row); err != nil {
+		return err
+	}
+	_row, err := t.parseRow(row)
+	if err != nil {
+		return err
+	}
+	t.footer = _row
+	t.widthsChecked = false
+	return nil
+}
+
+// writeFooter writes the line above the footer row and the footer row.
+func (t *Table) writeFooter(buf *bytes.Buffer, style *TableStyle) {
+	if t.footer == nil {
+		return
+	}
+
+	if t.summary == nil && style.LineBelowHeader.Visible() {
+		t.writeLine(buf, style, style.LineBelowHeader)
+	} else if t.summary != nil && style.LineBetweenRows.Visible() {
+		t.writeLine(buf, style, style.LineBetweenRows)
+	}
+
+	row := make([]string, 0, t.nColumns+1)
+	if t.rowNumbers {
+		row = append(row, "")
+	}
+	row = append(row, t.footer...)
+	for i := len(t.footer); i < t.nColumns; i++ { // only happens with flexible columns
+		row = append(row, "")
+	}
+	t.writeRow(buf, style, style.DataRow, row)
+}
+
+// writeSummary writes the line below the data rows and the summary row.
+func (t *Table) writeSummary(buf *bytes.Buffer, style *TableStyle) {
+	if t.summary == nil {
+		return
+	}
+
+	if style.LineBelowHeader.Visible() {
+		t.writeLine(buf, style, style.LineBelowHeader)
+	}
+
+	row := t.summaryRow()
+	if t.rowNumbers {
+		row = append([]string{""}, row...)
+	}
+
+	// numbers wrapped into multiple lines would be misread, so wider cells
+	// are clipped with a mark, as the widths are frozen in streaming mode.
+	if t.bufRowsDumped {
+		mark := t.frozenMark()
+		for i, v := range row {
+			if M := t.maxWidths[i]; t.width(v) > M {
+				row[i] = t.fit(t.truncate(v, M, mark), M)
+			}
+		}
+	}
+	t.writeRow(buf, style, style.DataRow, row)
+}
@@ -0,0 +1,218 @@
+// Copyright © 2023-2024 Wei Shen <shenwei356@gmail.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package stable
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestSummary(t *testing.T) {
+	newTable := func() *Table {
+		tbl := New().HumanizeNumbers()
+		tbl.Header([]string{"name", "a", "b", "c", "d", "e"})
+		tbl.AddRow([]interface{}{"x", 1000, 1.5, 3, "n/a", 1})
+		tbl.AddRow([]interface{}{"y", 2000, 2.5, -1, 5, 2})
+		tbl.AddRow([]interface{}{"z", 3000, 3.5, 7, 6, 3})
+		return tbl
+	}
+
+	tbl := newTable()
+	_, err := tbl.Summary(map[string]Aggregate{
+		"a": AggregateSum,
+		"b": AggregateMean,
+		"c": AggregateMin,
+		"d": AggregateCount,
+		"e": AggregateMax,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(string(tbl.Render(StyleSimple)), "\n"), "\n")
+	if got := strings.Fields(lines[len(lines)-2]); strings.Join(got, " ") != "summary 6,000 2.5 -1 2 3" {
+		t.Errorf("unexpected summary row: %q", lines[len(lines)-2])
+	}
+
+	tbl = newTable()
+	tbl.Summary(map[string]Aggregate{"a": AggregateSum})
+	lines = strings.Split(strings.TrimSuffix(string(tbl.Render(StyleSimple)), "\n"), "\n")
+	if got := strings.Fields(lines[len(lines)-2]); strings.Join(got, " ") != "sum 6,000" {
+		t.Errorf("unexpected summary row: %q", lines[len(lines)-2])
+	}
+
+	if _, err = tbl.Summary(map[string]Aggregate{"f": AggregateSum}); !errors.Is(err, ErrColumnNotFound) {
+		t.Errorf("expected ErrColumnNotFound, got %v", err)
+	}
+
+	// strict mode
+	tbl = New().SummaryStrict()
+	tbl.Header([]string{"name", "value"})
+	tbl.Summary(map[string]Aggregate{"value": AggregateSum})
+	if err = tbl.AddRow([]interface{}{"x", "n/a"}); !errors.Is(err, ErrNonNumericCell) {
+		t.Errorf("expected ErrNonNumericCell, got %v", err)
+	}
+
+	// streaming
+	var buf bytes.Buffer
+	tbl = New()
+	tbl.Writer(&buf, 2)
+	tbl.Header([]string{"name", "value"})
+	tbl.Summary(map[string]Aggregate{"value": AggregateSum})
+	for i := 1; i <= 100; i++ {
+		tbl.AddRow([]interface{}{"x", i})
+	}
+	tbl.Flush()
+	lines = strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if got := strings.Fields(lines[len(lines)-1]); strings.Join(got, " ") != "sum 5050" {
+		t.Errorf("unexpected summary row in streaming mode: %q", lines[len(lines)-1])
+	}
+
+	// the total outgrows the frozen column
+	buf.Reset()
+	tbl = New()
+	tbl.Writer(&buf, 2)
+	tbl.Header([]string{"name", "value"})
+	tbl.Summary(map[string]Aggregate{"value": AggregateSum})
+	for i := 1; i <= 1000; i++ {
+		tbl.AddRow([]interface{}{"x", i})
+	}
+	tbl.Flush()
+	lines = strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if got := lines[len(lines)-1]; got != "sum    5005…" {
+		t.Errorf("unexpected clipped summary row in streaming mode: %q", got)
+	}
+}
+
+func TestStreamSummary(t *testing.T) {
+	var buf bytes.Buffer
+	tbl := New().HumanizeNumbers().MinWidth(10) // widths are frozen after 100 rows
+	tbl.Writer(&buf, 100)
+	tbl.Style(StyleGrid)
+	tbl.Header([]string{"id", "value", "ratio"})
+	err := tbl.StreamSummary(map[string]Aggregate{
+		"value": AggregateSum,
+		"ratio": AggregateMax,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 1; i <= 10000; i++ {
+		ratio := interface{}(float64(i) / 10000)
+		if i%10 == 0 {
+			ratio = "NA"
+		}
+		tbl.AddRow([]interface{}{i, i, ratio})
+	}
+	tbl.Flush()
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if got := strings.Join(strings.Fields(lines[len(lines)-2]), " "); got != "| summary | 50,005,000 | 0.9999 |" {
+		t.Errorf("unexpected summary row: %q", got)
+	}
+	if skipped := tbl.SummarySkipped(); skipped["ratio"] != 1000 || skipped["value"] != 0 {
+		t.Errorf("unexpected skipped cells: %v", skipped)
+	}
+}
+
+func TestFooter(t *testing.T) {
+	build := func(tbl *Table) {
+		tbl.Header([]string{"id", "name"})
+		tbl.AddRow([]interface{}{1, "apple"})
+		tbl.AddRow([]interface{}{2, "banana"})
+		tbl.AddRow([]interface{}{3, "cherry"})
+		if err := tbl.Footer([]interface{}{"total", 3}); err != nil {
+			t.Error(err)
+		}
+	}
+	tbl := New()
+	build(tbl)
+	expect := `+-------+--------+
+| id    | name   |
++=======+========+
+| 1     | apple  |
++-------+--------+
+| 2     | banana |
++-------+--------+
+| 3     | cherry |
++=======+========+
+| total | 3      |
++-------+--------+
+`
+	if out := string(tbl.Render(StyleGrid)); out != expect {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+
+	// streaming, the footer is set after the buffered rows are written
+	var buf bytes.Buffer
+	tbl = New()
+	tbl.Writer(&buf, 1)
+	tbl.Style(StyleGrid)
+	tbl.Header([]string{"id", "name"})
+	tbl.AddRow([]interface{}{1, "banana"})
+	tbl.AddRow([]interface{}{2, "apple"})
+	tbl.AddRow([]interface{}{3, "cherry"})
+	tbl.Footer([]interface{}{"total", 3})
+	tbl.Flush()
+	expect = `+----+--------+
+| id | name   |
++====+========+
+| 1  | banana |
++----+--------+
+| 2  | apple  |
++----+--------+
+| 3  | cherry |
++====+========+
+| to | 3      |
+| ta |        |
+| l  |        |
++----+--------+
+`
+	if buf.String() != expect {
+		t.Errorf("unexpected output:\n%s", buf.String())
+	}
+
+	// the same as the in-memory one
+	buf.Reset()
+	tbl = New()
+	tbl.Writer(&buf, 5)
+	tbl.Style(StyleGrid)
+	build(tbl)
+	tbl.Flush()
+	tbl2 := New()
+	build(tbl2)
+	if expect = string(tbl2.Render(StyleGrid)); buf.String() != expect {
+		t.Errorf("unexpected output:\n%s", buf.String())
+	}
+
+	// errors
+	if err := tbl.Footer([]interface{}{1, 2}); err != ErrFooterAfterFlush {
+		t.Errorf("expected ErrFooterAfterFlush, got %v", err)
+	}
+	tbl = New()
+	tbl.Header([]string{"id", "name"})
+	if err := tbl.Footer([]interface{}{1}); !errors.Is(err, ErrUnmatchedColumnNumber) {
+		t.Errorf("expected ErrUnmatchedColumnNumber, got %v", err)
+	}
+	if err := tbl.Footer([]interface{}{1, struct{}{}}); err == nil {
+		t.Errorf("expected an error of converting values")
+	}
+}
@@ -24,11 +24,17 @@ import (
 	"fmt"
 	"io"
 	"math"
+	"strconv"
 	"strings"
 	"sync"
+	"time"
 	"unicode/utf8"
 
+	"github.com/dustin/go-humanize"
 	"github.com/mattn/go-runewidth"
+	"github.com/rivo/uniseg"
+	"golang.org/x/term"
+	"golang.org/x/text/unicode/norm"
 )
 
 // Align is the type of text alignment. Actually, there are only 3 values.
@@ -53,6 +59,28 @@ func (a Align) String() string {
 	}
 }
 
+// VAlign is the type of vertical alignment of cells in a row wrapped into multiple lines.
+type VAlign int
+
+const (
+	VAlignTop VAlign = iota + 1
+	VAlignMiddle
+	VAlignBottom
+)
+
+func (a VAlign) String() string {
+	switch a {
+	case VAlignTop:
+		return "top"
+	case VAlignMiddle:
+		return "middle"
+	case VAlignBottom:
+		return "bottom"
+	default:
+		return "unknown"
+	}
+}
+
 // DefaultConversionTable preset a table for converting special characters.
 var DefaultConversionTable = map[string]string{
 	"\t": " ",
@@ -68,11 +96,33 @@ var DefaultConversionTable = map[string]string{
 type Column struct {
 	Header string // column name
 	Align  Align  // text align
+	VAlign VAlign // vertical align for wrapped rows, it overrides the global VAlign of the table
 
 	MinWidth int // minimum width, it overrides the global MaxWidth of the table
 	MaxWidth int // maximum width, it overrides the global MaxWidth of the table
 
-	HumanizeNumbers bool // add comma to numbers, for example 1000 -> 1,000
+	// ConvertFunc transforms the raw value before the standard conversion, e.g.,
+	// unwrapping a sql.NullString, or converting nanoseconds to milliseconds,
+	// so other options like HumanizeNumbers still apply.
+	ConvertFunc func(v interface{}) (interface{}, error)
+
+	HumanizeNumbers   bool // add comma to numbers, for example 1000 -> 1,000
+	NoHumanizeNumbers bool // never add comma to numbers, it overrides the global HumanizeNumbers of the table, e.g., for IDs
+
+	DurationFormat func(d time.Duration) string // formatting time.Duration values, it overrides the global DurationFormat of the table
+	TimeLayout     string                       // layout of time.Time values, it overrides the global TimeLayout of the table
+
+	FloatFormat  byte // format of floats, i.e., 'f', 'e', or 'g', see strconv.FormatFloat, it overrides the global FloatFormat of the table
+	Precision    int  // precision of floats, *big.Float and *big.Rat values, with the format 'f' if not set, 0 for not set unless PrecisionSet is true
+	PrecisionSet bool // Precision is set even if it is 0, e.g., for rendering a column of floats as integers
+
+	SliceDelimiter string // delimiter for joining elements of slices, arrays, and maps, it overrides the global SliceDelimiter of the table
+
+	MergeCells bool // leave the cell blank if it equals the cell above it
+
+	ClipAtWord bool // clip cells at a word boundary, see Table.ClipAtWord
+
+	NoShrink bool // the column is not shrunk to fit the maximum total width, see Table.MaxTotalWidth
 }
 
 // Table is the table struct.
@@ -80,8 +130,10 @@ type Table struct {
 	rows [][]string // all rows, or buffered rows of the first bufRows lines when writer is set
 
 	convTable map[string]string // a table to convert special characters
+	keepCR    bool              // not normalizing line endings
 
 	columns   []Column // configuration of each column
+	rcolumns  []Column // columns to render, including the row-number column if needed
 	nColumns  int      // the number of the header or the first row
 	dataAdded bool     // a flag to indicate that some data is added, so calling SetHeader() is not allowed
 	hasHeader bool     // a flag to say the table has a header
@@ -89,34 +141,163 @@ type Table struct {
 	// statistics of data in rows
 	minWidths     []int // min width of each column, the value will be updated by the column or global option
 	maxWidths     []int // min width of each column, the value will be updated by the column or global option
-	widthsChecked bool  // a flag to indicate whether the min/max widths of each column is checked
+	widthsChecked bool  // a flag to indicate whether the min/max widths of each column is checked, it is reset by any change affecting widths
+	natWidths     []int // max width of each column before fitting the maximum total width, see fitWidths()
 
 	// global options set by users
-	align           Align  // text alignment
-	minWidth        int    // minimum width
-	maxWidth        int    // maximum width
-	wrapDelimiter   rune   // delimiter for wrapping cells
-	clipCell        bool   // clip cell instead of wrapping
-	clipMark        string // mark for indicating the cell if clipped
-	humanizeNumbers bool   // add comma to numbers, for example 1000 -> 1,000
+	align          Align                // text alignment
+	valign         VAlign               // vertical alignment
+	minWidth       int                  // minimum width
+	maxWidth       int                  // maximum width
+	wrapDelimiters string               // delimiters for wrapping cells
+	hyphen         rune                 // hyphen for breaking long words
+	clipCell       bool                 // clip cell instead of wrapping
+	clipMark       string               // mark for indicating the cell if clipped
+	clipAtWord     bool                 // clip cells at a word boundary
+	singleLine     bool                 // one line per row
+	newline        string               // replacement of newlines in single-line mode
+	newlineSet     bool                 // newline is set by NewlineReplacement()
+	preserveHeader bool                 // headers are not wrapped or clipped
+	sampleRows     int                  // the number of rows for estimating widths
+	rawWidths      bool                 // ANSI escape sequences are counted in widths
+	widthFunc      func(s string) int   // measuring the display width of text
+	condition      *runewidth.Condition // East Asian width setting
+
+	// limiting the total width
+	maxTotalWidth int
+	fitTerminal   bool
+
+	// cleaning text of cells and headers
+	trimCells      bool                // trimming leading and trailing whitespace of cells
+	collapseSpaces bool                // replacing runs of spaces and tabs in cells with a single space
+	normalize      bool                // normalizing Unicode text of cells
+	normForm       norm.Form           // the form of Unicode normalization
+	sanitize       func(r rune) string // replacing control characters in cells
+
+	// converting values to strings
+	humanizeNumbers bool                         // add comma to numbers, for example 1000 -> 1,000
+	strictNil       bool                         // nil values are not allowed
+	lenient         bool                         // formatting values of unsupported types with "%v"
+	durationFormat  func(d time.Duration) string // formatting time.Duration values
+	timeLayout      string                       // layout of time.Time values
+	ratFraction     bool                         // rendering *big.Rat values as fractions
+	sliceDelimiter  string                       // delimiter for joining elements of slices and arrays
+	floatFmt        byte                         // format of floats, see FloatFormat()
+	floatPrec       int                          // precision of floats, see FloatFormat()
+	floatFmtSet     bool                         // floatFmt and floatPrec are set by FloatFormat()
+	omitZeroImag    bool                         // rendering complex numbers with zero imaginary parts as real numbers
+
+	// decorating lines of the output
+	trimSpaces  bool                // trimming trailing spaces of borderless lines
+	padLeft     string              // left padding overriding the one of the style
+	padRight    string              // right padding overriding the one of the style
+	padLeftSet  bool                // padLeft is set by Padding() or PaddingLeft()
+	padRightSet bool                // padRight is set by Padding() or PaddingRight()
+	linePrefix  string              // prefix of every line
+	lineHook    func([]byte) []byte // post-processing every line, see LineHook()
+
+	// extra rows, columns, and lines
+	rowNumbers      bool                     // show row numbers
+	rowNumberHeader string                   // header of the row-number column
+	flexibleColumns bool                     // allow rows with different numbers of columns
+	repeatHeader    int                      // repeat the header every n data rows
+	zebra           func(line string) string // decorating lines of every other data row
+	caption         string                   // a footnote below the table
+
+	// sorting and grouping rows
+	sortIgnoreCase bool // compare strings case-insensitively in sorting
+	groupBy        bool
+	groupCol       int         // index of the grouping column
+	groupAggs      []Aggregate // aggregates of subtotals
 
 	// some reused datastructures, for avoiding allocate objects repeatedly
 	slice      []string     // for joining cells of each row
 	rotate     [][]string   // only for wrapping a row
+	offsets    []int        // only for vertical alignment of a wrapped row
 	wrappedRow []*[]string  // juonlyst for wrapping a row
 	poolSlice  *sync.Pool   // objects pool of string slice which size is the number of columns
+	poolSize   int          // size of slices in poolSlice
 	buf        bytes.Buffer // a bytes buffer
 
 	style *TableStyle // output style
 
+	// choosing the style according to the writer, see StyleAuto()
+	styleAuto bool
+	styleTTY  *TableStyle
+	stylePipe *TableStyle
+
+	// markers like section headers, which are placed before the data row of the index
+	markers map[int][]marker
+
+	// limiting the number of rendered rows
+	maxRows   int
+	nOmitted  int // the number of rows not rendered because of maxRows
+	rowOffset int // the number of rows before the first one, for rendering pages
+
+	// rendering pages
+	pageWidths    bool
+	seamlessPages bool
+
+	// raw values of rows
+	keepRaw bool
+	rawRows [][]interface{}
+
+	// errors
+	err       error // the first error of chainable methods, rendering, or writing
+	writeErr  error // the first error of writing, no more data is written after it
+	frozenErr error // the first width setter ignored after the widths are frozen, see ErrWidthsFrozen
+
+	// separators
+	emphasizeSeparators bool
+	afterSeparator      bool // a separator was just written
+	sepPending          bool // a separator added in streaming mode, written before the next row or section
+
+	// the summary row and the footer row
+	summary       []Aggregate  // aggregate of each column for the summary row
+	aggregators   []aggregator // accumulated aggregates of each column
+	summaryStrict bool         // non-numeric cells of aggregated columns are not allowed
+	summaryLabel  string       // the label of the summary row, the aggregate name by default
+	footer        []string     // the footer row, see Footer()
+
+	// collapsing identical consecutive rows
+	dedup        bool
+	dedupHeader  string   // header of the count column
+	dedupReady   bool     // the count column is determined
+	dedupAppend  bool     // the count column is appended
+	dedupCol     int      // index of the count column
+	pending      []string // the held-back row
+	pendingCount int      // the repeat count of the held-back row
+	pendingRaw   []interface{}
+	runCount     int // the repeat count of the last row in memory mode, 0 if the run is ended
+
+	// states of writing data rows
+	prevRow   []string // the previous data row written, for merging cells
+	rowNumber int      // the number of data rows written
+	merged    []bool   // whether the cell of each column is merged with the one above it
+
 	// if the writer is set, the first bufRows rows will  be used to determine
 	// the maximum width for each cell if they are not defined with MaxWidth().
 	writer        io.Writer
 	hasWriter     bool
-	bufRows       int  // the number of rows to determine the max/min width of each column
-	bufAll        bool // when bufRows is 0, just buffer all data
+	mu            *sync.Mutex // protecting adding rows and writing, see Concurrent()
+	bufRows       int         // the number of rows to determine the max/min width of each column
+	bufAll        bool        // when bufRows is 0, just buffer all data
 	bufRowsDumped bool
+	nStreamed     int // the number of rows written, including the buffered ones released after being dumped
 	flushed       bool
+	nBytes        int64       // the number of bytes written to the writer
+	teeWriters    []teeWriter // additional writers with their own styles, see AddWriter()
+
+	// buffering rows until the widths are stable, see WriterAdaptive()
+	adaptive     bool
+	minBufRows   int
+	stableFor    int
+	nStable      int   // the number of consecutive rows not changing the maximum widths
+	bufMaxWidths []int // the maximum width of each column of buffered rows
+
+	// reporting the progress
+	onRow     func(n int) // called after each row is written or appended, see OnRow()
+	nNotified int         // the number of rows passed to onRow
 }
 
 // New creates a new Table object.
@@ -124,6 +305,7 @@ func New() *Table {
 	t := new(Table)
 	t.style = StylePlain
 	t.convTable = DefaultConversionTable
+	t.sliceDelimiter = ", "
 	return t
 }
 
@@ -134,9 +316,166 @@ func New() *Table {
 // If you want to stream the output, please call this method before adding any rows.
 func (t *Table) Style(style *TableStyle) *Table {
 	t.style = style
+	t.styleAuto = false
+	return t
+}
+
+// StyleAuto chooses the style according to whether the writer is a terminal,
+// i.e., tty for a terminal, and pipe for others like pipes and files.
+// The style tty is used if the writer is not set.
+// The choice is made when the writer is set, or immediately if it is already set.
+// Calling Style() later overrides it.
+func (t *Table) StyleAuto(tty, pipe *TableStyle) *Table {
+	t.styleTTY, t.stylePipe = tty, pipe
+	t.styleAuto = true
+	t.chooseStyle()
+	return t
+}
+
+// isTerminal tells whether the file descriptor is a terminal.
+var isTerminal = term.IsTerminal
+
+// chooseStyle chooses the style for StyleAuto().
+func (t *Table) chooseStyle() {
+	if !t.styleAuto {
+		return
+	}
+	t.style = t.styleTTY
+	if !t.hasWriter {
+		return
+	}
+	if f, ok := t.writer.(interface{ Fd() uintptr }); !ok || !isTerminal(int(f.Fd())) {
+		t.style = t.stylePipe
+	}
+}
+
+// Padding sets the padding on both sides of cells, overriding the one of the style,
+// so there's no need to copy a style for changing the padding.
+// An empty string means no padding.
+func (t *Table) Padding(s string) *Table {
+	return t.PaddingLeft(s).PaddingRight(s)
+}
+
+// PaddingLeft sets the padding on the left side of cells, overriding the one of the style.
+func (t *Table) PaddingLeft(s string) *Table {
+	t.padLeft = s
+	t.padLeftSet = true
+	t.widthsChecked = false
+	return t
+}
+
+// PaddingRight sets the padding on the right side of cells, overriding the one of the style,
+// e.g., an empty one for dense numeric tables.
+func (t *Table) PaddingRight(s string) *Table {
+	t.padRight = s
+	t.padRightSet = true
+	t.widthsChecked = false
+	return t
+}
+
+// TrimTrailingSpaces removes trailing spaces of lines without a right border,
+// e.g., in StylePlain and StyleSimple, where a left-aligned last column
+// is padded to its maximum width. Bordered styles are not affected.
+func (t *Table) TrimTrailingSpaces() *Table {
+	t.trimSpaces = true
 	return t
 }
 
+// LinePrefix sets a prefix for every line of the output, e.g., spaces for indenting the table
+// in a Markdown list item. The prefix is not counted in MaxTotalWidth,
+// while its width is subtracted from the terminal width for FitTerminal.
+func (t *Table) LinePrefix(s string) *Table {
+	t.linePrefix = s
+	return t
+}
+
+// LineHook sets a function for post-processing every line of the output,
+// in both Render() and streaming mode, e.g., adding a timestamp to lines written to a logger.
+// The line, including the prefix set by LinePrefix(), is given without the trailing newline,
+// and it is replaced by the returned bytes. Returning nil drops the line.
+// The given slice is reused for the next line, so it should not be retained.
+// Column widths are not affected, as it is called after lines are assembled.
+func (t *Table) LineHook(f func(line []byte) []byte) *Table {
+	t.lineHook = f
+	return t
+}
+
+// prefixLines adds the line prefix to every line of the output,
+// and calls the line hook for every line.
+func (t *Table) prefixLines(data []byte) []byte {
+	if (t.linePrefix == "" && t.lineHook == nil) || len(data) == 0 {
+		return data
+	}
+	out := make([]byte, 0, len(data)+(bytes.Count(data, []byte{'\n'})+1)*len(t.linePrefix))
+	var line []byte
+	var n int
+	for len(data) > 0 {
+		i := bytes.IndexByte(data, '\n') + 1
+		if i == 0 {
+			i = len(data)
+		}
+		line = line[:0]
+		if t.trimSpaces && (data[0] == '\n') { // an empty line
+			line = append(line, strings.TrimRight(t.linePrefix, " ")...)
+		} else {
+			line = append(line, t.linePrefix...)
+		}
+		line = append(line, data[:i]...)
+		data = data[i:]
+
+		if t.lineHook == nil {
+			out = append(out, line...)
+			continue
+		}
+
+		n = len(line)
+		if line[n-1] != '\n' { // the last line without a newline
+			if hooked := t.lineHook(line); hooked != nil {
+				out = append(out, hooked...)
+			}
+			continue
+		}
+		if hooked := t.lineHook(line[:n-1]); hooked != nil {
+			out = append(out, hooked...)
+			out = append(out, '\n')
+		}
+	}
+	return out
+}
+
+// endLine writes the end of a line and a newline,
+// trailing spaces are removed for lines without a right border if needed.
+func (t *Table) endLine(buf *bytes.Buffer, end string) {
+	if end == "" && t.trimSpaces {
+		b := buf.Bytes()
+		n := len(b)
+		for n > 0 && b[n-1] == ' ' {
+			n--
+		}
+		buf.Truncate(n)
+	}
+	buf.WriteString(end)
+	buf.WriteString("\n")
+}
+
+// pads returns the left and right padding in use for the style.
+func (t *Table) pads(style *TableStyle) (string, string) {
+	left, right := style.Padding, style.Padding
+	if t.padLeftSet {
+		left = t.padLeft
+	}
+	if t.padRightSet {
+		right = t.padRight
+	}
+	return left, right
+}
+
+// padWidth returns the total width of the left and right padding.
+func (t *Table) padWidth(style *TableStyle) int {
+	left, right := t.pads(style)
+	return t.strWidth(left) + t.strWidth(right)
+}
+
 // ErrInvalidAlign means a invalid align value is given.
 var ErrInvalidAlign = fmt.Errorf("stable: invalid align value")
 
@@ -174,23 +513,69 @@ func (t *Table) Align(align Align) (*Table, error) {
 	return t, nil
 }
 
+// ErrInvalidVAlign means a invalid vertical align value is given.
+var ErrInvalidVAlign = fmt.Errorf("stable: invalid vertical align value")
+
+// VAlign sets the global vertical alignment of cells in a row wrapped into multiple lines.
+// Only three values are allowed: VAlignTop (default), VAlignMiddle, VAlignBottom.
+func (t *Table) VAlign(valign VAlign) (*Table, error) {
+	switch valign {
+	case VAlignTop, VAlignMiddle, VAlignBottom:
+		t.valign = valign
+	default:
+		return nil, ErrInvalidVAlign
+	}
+	return t, nil
+}
+
+// ErrWidthsFrozen means a width-affecting setter is called after the column widths
+// are determined and the buffered rows are written in streaming mode.
+var ErrWidthsFrozen = fmt.Errorf("stable: column widths can not be changed after the buffered rows are written")
+
+// widthsFrozen records ErrWidthsFrozen for the setter if the widths are determined
+// in streaming mode, and tells whether the setting should be ignored.
+// It is only a warning, so it's not recorded as the error of chainable methods.
+func (t *Table) widthsFrozen(setter string) bool {
+	if !t.bufRowsDumped {
+		return false
+	}
+	if t.frozenErr == nil {
+		t.frozenErr = fmt.Errorf("%w: %s", ErrWidthsFrozen, setter)
+	}
+	return true
+}
+
 // MinWidth sets the global minimum cell width.
+// There is no other minimum column width, except that a column is at least
+// one character wide, so columns of flags like "+"/"-" are not padded by default.
+// In streaming mode, it can be changed until the buffered rows are written,
+// after which the setting is ignored and ErrWidthsFrozen is recorded, see Err().
 func (t *Table) MinWidth(w int) *Table {
+	if t.widthsFrozen("MinWidth") {
+		return t
+	}
 	if t.maxWidth > 0 && w > t.maxWidth { // even bigger than t.maxWidth
 		t.minWidth = t.maxWidth
 	} else {
 		t.minWidth = w
 	}
+	t.widthsChecked = false
 	return t
 }
 
 // MaxWidth sets the global maximum cell width.
+// In streaming mode, it can be changed until the buffered rows are written,
+// after which the setting is ignored and ErrWidthsFrozen is recorded, see Err().
 func (t *Table) MaxWidth(w int) *Table {
+	if t.widthsFrozen("MaxWidth") {
+		return t
+	}
 	if t.minWidth > 0 && w < t.minWidth { // even smaller than t.minWidth
 		t.maxWidth = t.minWidth
 	} else {
 		t.maxWidth = w
 	}
+	t.widthsChecked = false
 	return t
 }
 
@@ -198,10 +583,60 @@ func (t *Table) MaxWidth(w int) *Table {
 // The default value is space.
 // Note that in streaming mode (after calling SetWriter())
 func (t *Table) WrapDelimiter(d rune) *Table {
+	return t.WrapDelimiters(d)
+}
+
+// WrapDelimiters sets multiple delimiters for wrapping cell text,
+// a line is broken after whichever of them comes last before the width limit,
+// e.g., ';' and ' ' for lineages with spaces in names.
+// The default value is space.
+func (t *Table) WrapDelimiters(ds ...rune) *Table {
 	if t.hasWriter && t.dataAdded {
 		return t
 	}
-	t.wrapDelimiter = d
+	t.wrapDelimiters = string(ds)
+	return t
+}
+
+// BreakLongWordsWithHyphen appends the hyphen to lines of wrapped cells,
+// which are broken in the middle of words longer than the column width.
+// The width of the hyphen is counted in the column width.
+// Lines broken after delimiters are not affected.
+func (t *Table) BreakLongWordsWithHyphen(hyphen rune) *Table {
+	t.hyphen = hyphen
+	return t
+}
+
+// EstimateWidths makes column widths determined by the header and the first sampleRows rows,
+// instead of all rows, which saves time for a very large table, as widths often converge
+// after a few thousand rows. Like in streaming mode, cells of later rows wider than the
+// estimated widths are wrapped, or clipped if ClipCell() is called.
+// 0 means using all rows, which is the default.
+func (t *Table) EstimateWidths(sampleRows int) *Table {
+	if sampleRows < 0 {
+		sampleRows = 0
+	}
+	t.sampleRows = sampleRows
+	t.widthsChecked = false
+	return t
+}
+
+// PreserveHeader exempts header cells from MaxWidth (global and column),
+// i.e., a column is at least as wide as its header, so headers are never
+// wrapped or clipped, while data cells still are.
+func (t *Table) PreserveHeader() *Table {
+	t.preserveHeader = true
+	t.widthsChecked = false
+	return t
+}
+
+// ClipAtWord makes clipped cells (see ClipCell) end at a word boundary, i.e., the text is
+// truncated to the width first, and then backed off to the last wrap delimiter,
+// unless that would remove more than half of the width.
+// Text of wide characters like CJK can be clipped at any position.
+// It can also be set for a column via Column.ClipAtWord.
+func (t *Table) ClipAtWord() *Table {
+	t.clipAtWord = true
 	return t
 }
 
@@ -212,18 +647,282 @@ func (t *Table) ClipCell(mark string) *Table {
 	return t
 }
 
+// SingleLine renders each row in exactly one line, i.e., cells are clipped (see ClipCell)
+// instead of being wrapped, and newlines in cells are replaced with a visible symbol
+// (see NewlineReplacement) instead of starting new lines.
+func (t *Table) SingleLine() *Table {
+	t.singleLine = true
+	t.clipCell = true
+	t.widthsChecked = false
+	return t
+}
+
+// NewlineReplacement sets the symbol replacing newlines in cells in single-line mode
+// (see SingleLine), the default value is "↵". Calling it also makes newlines replaced
+// in clipping mode (see ClipCell), where each line of a multi-line cell is clipped by default.
+func (t *Table) NewlineReplacement(s string) *Table {
+	t.newline = s
+	t.newlineSet = true
+	t.widthsChecked = false
+	return t
+}
+
+// joinLines tells whether newlines in cells are replaced.
+func (t *Table) joinLines() bool {
+	return t.singleLine || (t.clipCell && t.newlineSet)
+}
+
+// joinCellLines replaces newlines in a cell.
+func (t *Table) joinCellLines(s string) string {
+	if t.newlineSet {
+		return strings.ReplaceAll(s, "\n", t.newline)
+	}
+	return strings.ReplaceAll(s, "\n", "↵")
+}
+
 // HumanizeNumbers makes the numbers more readable by adding commas to numbers. E.g., 1000 -> 1,000.
+// Columns with the option NoHumanizeNumbers are not affected.
 func (t *Table) HumanizeNumbers() *Table {
 	t.humanizeNumbers = true
 	return t
 }
 
+// DurationFormat sets a function for formatting time.Duration values, which are formatted
+// with Duration.String() by default, e.g., DurationHMS for "HH:MM:SS", or rounding to milliseconds
+// for uniform column widths:
+//
+//	tbl.DurationFormat(func(d time.Duration) string { return d.Round(time.Millisecond).String() })
+//
+// Durations are never humanized with commas.
+func (t *Table) DurationFormat(f func(d time.Duration) string) *Table {
+	t.durationFormat = f
+	return t
+}
+
+// TimeLayout sets the layout for formatting time.Time values, the default one is time.RFC3339.
+// The monotonic clock reading is never shown, and zero times are rendered as empty cells.
+func (t *Table) TimeLayout(layout string) *Table {
+	t.timeLayout = layout
+	return t
+}
+
+// FloatFormat sets the format and precision for all floats, including *big.Float values,
+// in both the plain and humanized modes, e.g., FloatFormat('f', 2) renders 0.1+0.2 as "0.30".
+// The arguments are the same as these of strconv.FormatFloat,
+// and the column options FloatFormat and Precision override them.
+// With a precision, humanized floats keep trailing zeros, e.g., 2000 -> "2,000.00",
+// so decimal points of a right-aligned column line up.
+// Floats are formatted with the format 'g' and the smallest precision by default,
+// and humanized floats have no trailing zeros.
+func (t *Table) FloatFormat(format byte, prec int) *Table {
+	t.floatFmt, t.floatPrec, t.floatFmtSet = format, prec, true
+	return t
+}
+
+// OmitZeroImaginary renders complex numbers with zero imaginary parts as real numbers,
+// e.g., "1.5" instead of "1.5+0i". Complex numbers are formatted like "a+bi",
+// with the format and precision of floats, and they are never humanized.
+func (t *Table) OmitZeroImaginary() *Table {
+	t.omitZeroImag = true
+	return t
+}
+
+// SliceDelimiter sets the delimiter for joining elements of slices and arrays, the default is ", ".
+// Elements are converted individually, e.g., numbers are humanized if needed,
+// while nested slices are not supported. Empty slices are rendered as empty cells.
+// It is also used for joining entries of maps with string keys, like "k1=v1, k2=v2", with sorted keys.
+// Long lists are wrapped at spaces, or at the delimiters if they are added with WrapDelimiters().
+func (t *Table) SliceDelimiter(s string) *Table {
+	t.sliceDelimiter = s
+	return t
+}
+
+// RatFraction renders *big.Rat values as fractions like "1/3", instead of decimals.
+func (t *Table) RatFraction() *Table {
+	t.ratFraction = true
+	return t
+}
+
+// LenientConversion formats values of unsupported types, e.g., structs, with fmt.Sprintf("%v"),
+// instead of failing to add the row. It is handy for quick debugging tables,
+// and long outputs are wrapped or clipped like other cells.
+func (t *Table) LenientConversion() *Table {
+	t.lenient = true
+	return t
+}
+
+// StrictNil makes adding a row with nil values, including typed nil pointers, fail,
+// instead of rendering them as empty cells.
+func (t *Table) StrictNil() *Table {
+	t.strictNil = true
+	return t
+}
+
 // Convert uses a custom map to replace the DefaultConversionTable for converting special characters.
+// If newlines are not converted, cells containing them are rendered in multiple lines,
+// and each line is wrapped or clipped independently.
+// Line endings "\r\n" and "\r" are normalized to "\n" before the conversion,
+// unless KeepCarriageReturns() is called.
 func (t *Table) Convert(m map[string]string) *Table {
 	t.convTable = m
 	return t
 }
 
+// KeepCarriageReturns disables normalizing line endings "\r\n" and "\r" of cells to "\n".
+func (t *Table) KeepCarriageReturns() *Table {
+	t.keepCR = true
+	return t
+}
+
+// ShowRowNumbers prepends a column of 1-based row numbers when rendering,
+// with the given header. The column is right-aligned and it is not a part of
+// the data, i.e., rows are added as usual, and column indexes are not changed.
+// In streaming mode, the width of the column is reserved for at least 6 digits
+// when the buffered rows are written, as the widths can not be changed later,
+// and bigger numbers are clipped to their last digits following the mark of
+// ClipCell() ("…" by default), e.g., "…00000" for 1,000,000.
+func (t *Table) ShowRowNumbers(header string) *Table {
+	t.rowNumbers = true
+	t.rowNumberHeader = header
+	t.widthsChecked = false
+	return t
+}
+
+// RepeatHeaderEvery repeats the header row (and the line below it)
+// after every n data rows, which is helpful for long tables.
+// A wrapped row counts as one row.
+// The header is not repeated if no more rows follow.
+func (t *Table) RepeatHeaderEvery(n int) *Table {
+	t.repeatHeader = n
+	return t
+}
+
+// Zebra sets a function to decorate lines of every other data row,
+// i.e., the 2nd, 4th, ... rows, for improving readability of long tables.
+// For example, adding ANSI escape codes of a background color.
+// It is applied to the assembled lines (including all lines of a wrapped row,
+// but not border lines), so column widths are not affected.
+func (t *Table) Zebra(decorate func(line string) string) *Table {
+	t.zebra = decorate
+	return t
+}
+
+// FlexibleColumns allows rows with different numbers of columns.
+// A longer row appends columns with empty headers, and shorter rows
+// are padded with empty cells.
+// In streaming mode, rows can not be longer than the written ones.
+func (t *Table) FlexibleColumns() *Table {
+	t.flexibleColumns = true
+	return t
+}
+
+// MaxRows limits the number of rendered rows. If there are more rows,
+// only the first n ones are rendered, followed by a line spanning all columns
+// saying how many rows are omitted. Markers after the n-th row are omitted too,
+// while the summary row is computed from all rows.
+// In streaming mode, rows after the n-th one are counted but not written.
+// 0 means no limit.
+func (t *Table) MaxRows(n int) *Table {
+	if n < 0 {
+		n = 0
+	}
+	t.maxRows = n
+	return t
+}
+
+// Caption sets a footnote which is written below the bottom line.
+// It is left-aligned and wrapped to the width of the table, and it does not
+// affect the column widths. Newlines are kept.
+func (t *Table) Caption(s string) *Table {
+	t.caption = s
+	return t
+}
+
+// marker is a special row placed between data rows.
+type marker struct {
+	label     string   // label of a section header
+	skip      int      // the number of omitted rows, for keeping row numbers
+	separator bool     // a horizontal line
+	cells     []string // a row like subtotals, which is not a data row
+}
+
+// ErrAddMarkerAfterFlush means that adding sections or separators is not allowed after calling Flush().
+var ErrAddMarkerAfterFlush = fmt.Errorf("stable: adding sections or separators is not allowed after calling Flush()")
+
+// AddSection adds a section header, which is rendered as a single centered
+// cell spanning all columns, for grouping the following rows.
+// The label does not affect the column widths, and it is wrapped if it is
+// longer than the table width.
+// In streaming mode, it is written immediately if the buffered rows are dumped.
+func (t *Table) AddSection(label string) error {
+	return t.addMarker(marker{label: t.convertCharacters(label)})
+}
+
+// AddSeparator adds a horizontal line between the previous and following rows,
+// for visually grouping rows without a label.
+// LineBetweenRows of the style is used, or LineBelowHeader if EmphasizeSeparators()
+// is called. If the line is not visible in the style, the other one is used,
+// and for styles without both, like plain, a dashed line is drawn.
+// Consecutive separators are collapsed into one, and a separator right below
+// the header is ignored.
+// A separator after the last row is ignored too, as the bottom line follows.
+// In streaming mode, it is written before the next row or section if the
+// buffered rows are dumped.
+func (t *Table) AddSeparator() error {
+	return t.addMarker(marker{separator: true})
+}
+
+// EmphasizeSeparators makes separators added by AddSeparator() use
+// LineBelowHeader of the style, instead of LineBetweenRows.
+func (t *Table) EmphasizeSeparators() *Table {
+	t.emphasizeSeparators = true
+	return t
+}
+
+// addMarker adds a marker before the next data row.
+func (t *Table) addMarker(m marker) error {
+	if t.mu != nil {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+	}
+	if t.hasWriter && t.flushed {
+		return ErrAddMarkerAfterFlush
+	}
+	if err := t.commitPending(); err != nil {
+		return err
+	}
+
+	if t.bufRowsDumped {
+		if t.maxRows > 0 && len(t.rows)+t.nStreamed >= t.maxRows {
+			return nil
+		}
+
+		style := t.style
+		if style == nil { // not defined in the object
+			style = StyleGrid
+		}
+
+		// a separator is held back, as it's dropped if no rows follow,
+		// like in Render().
+		if m.separator {
+			t.sepPending = true
+			return t.writeErr
+		}
+		t.emit("section", style, func(buf *bytes.Buffer, style *TableStyle) {
+			t.writePendingSeparator(buf, style)
+			t.writeMarker(buf, style, m, false)
+		})
+		return t.writeErr
+	}
+
+	if t.markers == nil {
+		t.markers = make(map[int][]marker)
+	}
+	t.markers[len(t.rows)] = append(t.markers[len(t.rows)], m)
+	t.widthsChecked = false
+	return nil
+}
+
 // --------------------------------------------------------------------------
 // ErrSetHeaderAfterDataAdded means that setting header is not allowed after some data being added.
 var ErrSetHeaderAfterDataAdded = fmt.Errorf("stable: setting header is not allowed after some data being added")
@@ -236,14 +935,15 @@ func (t *Table) Header(headers []string) (*Table, error) {
 	t.columns = make([]Column, len(headers))
 	for i, h := range headers {
 		t.columns[i] = Column{
-			Header: h,
+			Header: t.sanitizeCell(t.cleanCell(h)),
 		}
 	}
 	t.nColumns = len(headers)
+	t.widthsChecked = false
 
 	hasNonEmptyHeader := false
-	for _, header := range headers {
-		if header != "" {
+	for _, c := range t.columns {
+		if c.Header != "" {
 			hasNonEmptyHeader = true
 			break
 		}
@@ -253,6 +953,11 @@ func (t *Table) Header(headers []string) (*Table, error) {
 	return t, nil
 }
 
+// HeaderValues is a variadic version of Header.
+func (t *Table) HeaderValues(headers ...string) (*Table, error) {
+	return t.Header(headers)
+}
+
 // HeaderWithFormat sets column names and other configuration of the column.
 func (t *Table) HeaderWithFormat(headers []Column) (*Table, error) {
 	if t.dataAdded {
@@ -260,9 +965,11 @@ func (t *Table) HeaderWithFormat(headers []Column) (*Table, error) {
 	}
 	t.columns = headers
 	t.nColumns = len(headers)
+	t.widthsChecked = false
+	t.cleanHeaders()
 
 	hasNonEmptyHeader := false
-	for _, header := range headers {
+	for _, header := range t.columns {
 		if header.Header != "" {
 			hasNonEmptyHeader = true
 			break
@@ -280,6 +987,91 @@ func (t *Table) HasHeaders() bool {
 	return t.hasHeader
 }
 
+// HasHeader is the same as HasHeaders.
+func (t *Table) HasHeader() bool {
+	return t.hasHeader
+}
+
+// NumRows returns the number of data rows, the header is not counted.
+// In streaming mode, it returns the number of all rows added, including written ones.
+func (t *Table) NumRows() int {
+	if t.pending != nil {
+		return len(t.rows) + t.nStreamed + 1
+	}
+	return len(t.rows) + t.nStreamed
+}
+
+// Rows returns a copy of the data rows, which are converted strings
+// before wrapping or clipping.
+// In streaming mode, only the buffered rows are returned, which are released
+// after being written.
+func (t *Table) Rows() [][]string {
+	rows := make([][]string, len(t.rows))
+	for i, row := range t.rows {
+		rows[i] = make([]string, len(row))
+		copy(rows[i], row)
+	}
+	return rows
+}
+
+// ErrKeepRawValuesInStreamingMode means that raw values can not be kept in streaming mode.
+var ErrKeepRawValuesInStreamingMode = fmt.Errorf("stable: keeping raw values is not supported in streaming mode")
+
+// KeepRawValues keeps the original values of rows alongside the converted strings,
+// which can be retrieved with RawRows(). Sorting prefers numeric raw values
+// if there are. It costs more memory, so it is disabled by default.
+// It should be called before adding any rows, and it is not supported
+// in streaming mode, i.e., Writer() returns an error after calling it,
+// and it has no effect after Writer() being called.
+func (t *Table) KeepRawValues() *Table {
+	if t.dataAdded || t.hasWriter {
+		return t
+	}
+	t.keepRaw = true
+	return t
+}
+
+// RawRows returns a copy of the original values of data rows,
+// cells padded by FlexibleColumns are nil.
+// It returns nil if KeepRawValues() is not called.
+func (t *Table) RawRows() [][]interface{} {
+	if !t.keepRaw {
+		return nil
+	}
+	rows := make([][]interface{}, len(t.rawRows))
+	for i, row := range t.rawRows {
+		rows[i] = make([]interface{}, len(row))
+		copy(rows[i], row)
+	}
+	return rows
+}
+
+// rawRow returns a copy of the row padded to the number of columns.
+func (t *Table) rawRow(row []interface{}) []interface{} {
+	raw := make([]interface{}, t.nColumns)
+	copy(raw, row)
+	return raw
+}
+
+// Headers returns a copy of the column names.
+// It returns nil if the header is not set.
+func (t *Table) Headers() []string {
+	if !t.hasHeader {
+		return nil
+	}
+	headers := make([]string, len(t.columns))
+	for i, c := range t.columns {
+		headers[i] = c.Header
+	}
+	return headers
+}
+
+// NumColumns returns the number of columns, which is determined by the header
+// or the first row. The row-number column is not counted.
+func (t *Table) NumColumns() int {
+	return t.nColumns
+}
+
 // ErrUnmatchedColumnNumber means that the column number
 // of the newly added row is not matched with that of previous ones.
 var ErrUnmatchedColumnNumber = fmt.Errorf("stable: unmatched column number")
@@ -289,70 +1081,352 @@ func (t *Table) parseRow(row []interface{}) ([]string, error) {
 	_row := make([]string, len(row))
 	var err error
 	var s string
-	var humanizeNumbers bool
 	for i, v := range row {
-		if t.humanizeNumbers {
-			humanizeNumbers = true
-		} else {
-			humanizeNumbers = t.columns[i].HumanizeNumbers
-		}
-
-		s, err = t.convertToString(v, humanizeNumbers)
+		s, err = t.convertCell(v, i)
 		if err != nil {
 			return nil, err
 		}
-		_row[i] = s
+		_row[i] = t.sanitizeCell(t.cleanCell(s))
 	}
 	return _row, nil
 }
 
 // checkRow checks a row.
 func (t *Table) checkRow(row []interface{}) ([]string, error) {
-	if t.hasHeader {
-		if len(row) != t.nColumns {
-			return nil, ErrUnmatchedColumnNumber
-		}
-	} else if t.columns == nil { // no header and the t.columns is nil
-		t.columns = make([]Column, len(row))
-		for i := 0; i < len(row); i++ {
+	if err := t.checkColumns(len(row), nil, row); err != nil {
+		return nil, err
+	}
+
+	_row, err := t.parseRow(row)
+	if err != nil {
+		return nil, err
+	}
+	for len(_row) < t.nColumns { // only happens with flexible columns
+		_row = append(_row, "")
+	}
+	if err = t.checkSummary(_row); err != nil {
+		return nil, err
+	}
+	return _row, nil
+}
+
+// checkColumns checks the number of cells of a row.
+// The cells of strings or values are only used in the error message.
+func (t *Table) checkColumns(n int, cells []string, values []interface{}) error {
+	if !t.hasHeader && t.columns == nil { // no header and the t.columns is nil
+		t.columns = make([]Column, n)
+		for i := 0; i < n; i++ {
 			t.columns[i] = Column{}
 		}
-		t.nColumns = len(row)
-	} else { // no header
-		if len(row) != t.nColumns {
-			return nil, ErrUnmatchedColumnNumber
+		t.nColumns = n
+	} else if n != t.nColumns {
+		if !t.flexibleColumns {
+			return t.unmatchedColumnNumber(n, cells, values)
+		}
+		if n > t.nColumns {
+			if err := t.growColumns(n); err != nil {
+				return err
+			}
 		}
 	}
-
-	return t.parseRow(row)
+	return nil
 }
 
-var ErrAddRowAfterFlush = fmt.Errorf("stable: calling AddRow is not allowed after calling Flush()")
+// nPreviewCells is the number of the first cells of a row shown in the error message.
+const nPreviewCells = 3
 
-func (t *Table) AddRowStringSlice(row []string) error {
-	tmp := make([]interface{}, len(row))
-	for i, v := range row {
-		tmp[i] = v
+// unmatchedColumnNumber returns ErrUnmatchedColumnNumber wrapped with the expected
+// and actual numbers of columns, and the first few cells of the row.
+func (t *Table) unmatchedColumnNumber(n int, cells []string, values []interface{}) error {
+	preview := make([]string, 0, nPreviewCells)
+	for i := 0; i < n && i < nPreviewCells; i++ {
+		if cells != nil {
+			preview = append(preview, strconv.Quote(cells[i]))
+		} else {
+			preview = append(preview, fmt.Sprintf("%#v", values[i]))
+		}
 	}
-
-	return t.AddRow(tmp)
+	if n > nPreviewCells {
+		preview = append(preview, "...")
+	}
+	return fmt.Errorf("%w: expected %d, got %d, in the row of [%s]",
+		ErrUnmatchedColumnNumber, t.nColumns, n, strings.Join(preview, ", "))
 }
 
-// AddRow adds a row.
-func (t *Table) AddRow(row []interface{}) error {
-	if t.hasWriter && t.flushed {
-		return ErrAddRowAfterFlush
-	}
+// ErrGrowColumnsAfterRowsWritten means that adding a row with more columns
+// is not allowed after some rows being written in streaming mode.
+var ErrGrowColumnsAfterRowsWritten = fmt.Errorf("stable: adding a row with more columns is not allowed after some rows being written")
 
-	// just adds it to buffer
-	if !t.hasWriter || t.bufAll || len(t.rows) < t.bufRows {
-		_row, err := t.checkRow(row)
-		if err != nil {
-			return err
+// growColumns appends columns with empty headers,
+// and existing rows are padded with empty cells.
+func (t *Table) growColumns(n int) error {
+	if t.bufRowsDumped {
+		return ErrGrowColumnsAfterRowsWritten
+	}
+	for len(t.columns) < n {
+		t.columns = append(t.columns, Column{})
+	}
+	for i, row := range t.rows {
+		for len(row) < n {
+			row = append(row, "")
+		}
+		t.rows[i] = row
+	}
+	for i, row := range t.rawRows {
+		for len(row) < n {
+			row = append(row, nil)
+		}
+		t.rawRows[i] = row
+	}
+	if t.summary != nil {
+		for len(t.summary) < n {
+			t.summary = append(t.summary, 0)
+			t.aggregators = append(t.aggregators, aggregator{})
+		}
+	}
+	t.nColumns = n
+	t.widthsChecked = false
+	return nil
+}
+
+var ErrAddRowAfterFlush = fmt.Errorf("stable: calling AddRow is not allowed after calling Flush()")
+
+// AddRowStringSlice adds a row of strings.
+func (t *Table) AddRowStringSlice(row []string) error {
+	tmp := make([]interface{}, len(row))
+	for i, v := range row {
+		tmp[i] = v
+	}
+
+	return t.AddRow(tmp)
+}
+
+// AddRowValues is a variadic version of AddRow.
+func (t *Table) AddRowValues(vals ...interface{}) error {
+	return t.AddRow(vals)
+}
+
+// AddRowsFromSlices adds rows of strings. Unlike AddRowStringSlice,
+// cells are not boxed into interface{} values and converted back,
+// so it is faster and allocates less memory for a large number of rows.
+// Cells are still converted like the ones of AddRowStringSlice if any column
+// has a ConvertFunc, so other options like HumanizeNumbers apply to the results.
+// Rows are copied, and it stops at the first invalid row.
+func (t *Table) AddRowsFromSlices(rows [][]string) error {
+	if t.mu != nil {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+	}
+	for i, row := range rows {
+		if err := t.addRowStrings(row); err != nil {
+			return fmt.Errorf("row %d: %w", i+1, err)
+		}
+	}
+	return nil
+}
+
+// addRowStrings adds a row of strings without the conversion of values.
+func (t *Table) addRowStrings(row []string) error {
+	if t.hasWriter && t.flushed {
+		return ErrAddRowAfterFlush
+	}
+	if t.dedup || t.keepRaw || t.hasConvertFunc() { // they need the values
+		tmp := make([]interface{}, len(row))
+		for i, v := range row {
+			tmp[i] = v
+		}
+		return t.addRowValues(tmp)
+	}
+
+	if err := t.checkColumns(len(row), row, nil); err != nil {
+		return err
+	}
+
+	_row := make([]string, len(row), t.nColumns)
+	copy(_row, row)
+	if len(t.convTable) > 0 || t.sanitize != nil || !t.keepCR || t.trimCells || t.collapseSpaces || t.normalize {
+		for i, s := range _row {
+			_row[i] = t.sanitizeCell(t.cleanCell(t.convertCharacters(s)))
+		}
+	}
+	for len(_row) < t.nColumns { // only happens with flexible columns
+		_row = append(_row, "")
+	}
+	if err := t.checkSummary(_row); err != nil {
+		return err
+	}
+	return t.addRow(_row)
+}
+
+// hasConvertFunc tells whether any column has a ConvertFunc.
+func (t *Table) hasConvertFunc() bool {
+	for _, c := range t.columns {
+		if c.ConvertFunc != nil {
+			return true
 		}
+	}
+	return false
+}
+
+// ErrInvalidRowIndex means the row index is out of range.
+var ErrInvalidRowIndex = fmt.Errorf("stable: invalid row index")
+
+// ErrEditAfterRowsWritten means that editing rows is not allowed
+// after some rows being written in streaming mode.
+var ErrEditAfterRowsWritten = fmt.Errorf("stable: editing rows is not allowed after some rows being written")
+
+// checkRowIndex checks if rows can be edited and the row index is valid.
+func (t *Table) checkRowIndex(i int) error {
+	if t.bufRowsDumped || (t.hasWriter && t.flushed) {
+		return ErrEditAfterRowsWritten
+	}
+	if i < 0 || i >= len(t.rows) {
+		return fmt.Errorf("%w: %d, the table has %d rows", ErrInvalidRowIndex, i, len(t.rows))
+	}
+	return nil
+}
+
+// RemoveRow removes the i-th (0-based) row.
+// In streaming mode, only buffered rows can be removed.
+func (t *Table) RemoveRow(i int) error {
+	if err := t.checkRowIndex(i); err != nil {
+		return err
+	}
+	t.rows = append(t.rows[:i], t.rows[i+1:]...)
+	t.runCount = 0
+	if t.keepRaw {
+		t.rawRows = append(t.rawRows[:i], t.rawRows[i+1:]...)
+	}
+
+	// markers after the row move forward
+	if len(t.markers) > 0 {
+		markers := make(map[int][]marker, len(t.markers))
+		for j, ms := range t.markers {
+			if j > i {
+				j--
+			}
+			markers[j] = append(markers[j], ms...)
+		}
+		t.markers = markers
+	}
+
+	t.widthsChecked = false
+	return nil
+}
+
+// UpdateRow replaces the i-th (0-based) row.
+// In streaming mode, only buffered rows can be updated.
+func (t *Table) UpdateRow(i int, row []interface{}) error {
+	if err := t.checkRowIndex(i); err != nil {
+		return err
+	}
+	_row, err := t.checkRow(row)
+	if err != nil {
+		return err
+	}
+	t.rows[i] = _row
+	t.runCount = 0
+	if t.keepRaw {
+		t.rawRows[i] = t.rawRow(row)
+	}
+
+	t.widthsChecked = false
+	return nil
+}
+
+// UpdateCell replaces the cell in the given row and column (both 0-based).
+// In streaming mode, only buffered rows can be updated.
+func (t *Table) UpdateCell(row, col int, v interface{}) error {
+	if err := t.checkRowIndex(row); err != nil {
+		return err
+	}
+	if col < 0 || col >= t.nColumns {
+		return fmt.Errorf("%w: %d, the table has %d columns", ErrInvalidColumnIndex, col, t.nColumns)
+	}
+
+	s, err := t.convertCell(v, col)
+	if err != nil {
+		return err
+	}
+
+	_row := make([]string, len(t.rows[row]))
+	copy(_row, t.rows[row])
+	_row[col] = t.sanitizeCell(t.cleanCell(s))
+	if err = t.checkSummary(_row); err != nil {
+		return err
+	}
+	t.rows[row] = _row
+	t.runCount = 0
+	if t.keepRaw {
+		t.rawRows[row][col] = v
+	}
+
+	t.widthsChecked = false
+	return nil
+}
+
+// AddRow adds a row.
+// In streaming mode, rows are written by it, and the first error of writing is returned
+// by it and all later calls, as no more data is written after it.
+func (t *Table) AddRow(row []interface{}) error {
+	if t.mu != nil {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+	}
+	return t.addRowValues(row)
+}
+
+// AddRows adds rows of values, it stops at the first invalid row,
+// and the error is wrapped with the 1-based row index, e.g., "row 3: ...".
+func (t *Table) AddRows(rows [][]interface{}) error {
+	if t.mu != nil {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+	}
+	for i, row := range rows {
+		if err := t.addRowValues(row); err != nil {
+			return fmt.Errorf("row %d: %w", i+1, err)
+		}
+	}
+	return nil
+}
+
+// addRowValues adds a row of values, it's not protected by the mutex.
+func (t *Table) addRowValues(row []interface{}) error {
+	if t.hasWriter && t.flushed {
+		return ErrAddRowAfterFlush
+	}
+
+	if t.dedup {
+		return t.addRowDedup(row)
+	}
+
+	_row, err := t.checkRow(row)
+	if err != nil {
+		return err
+	}
+	if t.keepRaw {
+		t.rawRows = append(t.rawRows, t.rawRow(row))
+	}
+	return t.addRow(_row)
+}
+
+// addRow adds a parsed and checked row.
+func (t *Table) addRow(_row []string) error {
+	if t.writeErr != nil { // the writer failed
+		return t.writeErr
+	}
+
+	// just adds it to buffer
+	if !t.hasWriter || t.bufAll ||
+		(!t.bufRowsDumped && len(t.rows) < t.bufRows && !t.widthsStable(_row)) {
 		t.rows = append(t.rows, _row)
 		t.dataAdded = true
+		t.widthsChecked = false
 
+		if !t.hasWriter {
+			t.notifyRows(1)
+		}
 		return nil
 	}
 
@@ -363,207 +1437,496 @@ func (t *Table) AddRow(row []interface{}) error {
 		style = StyleGrid
 	}
 
-	buf := t.buf
-	buf.Reset()
+	// ------------------------------------------------
 
-	if t.slice == nil {
-		t.slice = make([]string, t.nColumns)
-	}
-	slice := t.slice
+	if t.bufRowsDumped {
+		if t.summary != nil {
+			t.accumulate(_row)
+		}
+		if t.maxRows > 0 && len(t.rows)+t.nStreamed >= t.maxRows {
+			t.nStreamed++
+			t.nOmitted++
+			return nil
+		}
+		t.nStreamed++
 
-	lenPad2 := len(style.Padding) * 2
-	var wrapped bool
+		// data row and the line above it
+		t.emit("data row", style, func(buf *bytes.Buffer, style *TableStyle) {
+			t.writePendingSeparator(buf, style)
+			t.writeDataRow(buf, style, _row, false)
+		})
 
-	var row2 *[]string
+		if t.writeErr == nil {
+			t.notifyRows(1)
+		}
+		return t.writeErr
+	}
 
 	// ------------------------------------------------
 
-	if t.bufRowsDumped {
-		// ------------------------------------------------
-		// parse and check row
-		_row, err := t.checkRow(row)
-		if err != nil {
-			return err
+	// enough rows are buffered, or the widths are stable
+	if !t.bufRowsDumped {
+		// determine the minWidth and maxWidth
+		t.checkWidths()
+		if err := t.fitWidths(style); err != nil && t.err == nil {
+			t.err = err
 		}
 
-		// ------------------------------------------------
+		t.rows = append(t.rows, _row)
+		t.dataAdded = true
+		if t.summary != nil {
+			t.accumulate(_row)
+		}
 
-		// line between rows
-		if style.LineBetweenRows.Visible() {
-			buf.WriteString(style.LineBetweenRows.Begin)
-			for i, M := range t.maxWidths {
-				slice[i] = strings.Repeat(style.LineBetweenRows.Hline, M+lenPad2)
-			}
-			buf.WriteString(strings.Join(slice, style.LineBetweenRows.Sep))
-			buf.WriteString(style.LineBetweenRows.End)
-			buf.WriteString("\n")
+		// write the top line, the header, and the rows
+		t.emit("header and buffered rows", style, func(buf *bytes.Buffer, style *TableStyle) {
+			t.writeHead(buf, style)
+			t.writeBody(buf, style, true)
+		})
 
-			t.writer.Write(buf.Bytes())
-			buf.Reset()
+		t.bufRowsDumped = true
+
+		if t.writeErr == nil {
+			t.notifyRows(t.nRowsShown())
 		}
 
-		// data row
-		wrapped = t.formatRow(_row)
-		if wrapped {
-			for _, row2 = range t.wrappedRow {
-				buf.WriteString(style.DataRow.Begin)
-				for i, M := range t.maxWidths {
-					slice[i] = style.Padding + t.formatCell((*row2)[i], M, t.columns[i].Align) + style.Padding
-				}
-				buf.WriteString(strings.Join(slice, style.DataRow.Sep))
-				buf.WriteString(style.DataRow.End)
-				buf.WriteString("\n")
+		// the buffered rows are never read again, release them
+		t.nStreamed += len(t.rows)
+		t.rows, t.markers = nil, nil
+	}
 
-				t.writer.Write(buf.Bytes())
-				buf.Reset()
+	return t.writeErr
+}
 
-				t.poolSlice.Put(row2)
-			}
-		} else {
-			buf.WriteString(style.DataRow.Begin)
-			for i, M := range t.maxWidths {
-				slice[i] = style.Padding + t.formatCell(_row[i], M, t.columns[i].Align) + style.Padding
-			}
-			buf.WriteString(strings.Join(slice, style.DataRow.Sep))
-			buf.WriteString(style.DataRow.End)
-			buf.WriteString("\n")
+// OnRow sets a callback function which is called after each data row is written in streaming mode,
+// or appended in memory mode, with n being the 1-based running count of rows.
+// It is not called for the header, lines, or rows omitted by MaxRows().
+// In streaming mode, buffered rows are reported after they are written.
+func (t *Table) OnRow(f func(n int)) *Table {
+	t.onRow = f
+	return t
+}
+
+// notifyRows calls the callback function for n more rows.
+// The counter is increased before calling it, so a panicking callback would not
+// report the same row again.
+func (t *Table) notifyRows(n int) {
+	if t.onRow == nil {
+		return
+	}
+	for i := 0; i < n; i++ {
+		t.nNotified++
+		t.onRow(t.nNotified)
+	}
+}
+
+// nRowsShown returns the number of buffered rows which are shown.
+func (t *Table) nRowsShown() int {
+	if t.maxRows > 0 && len(t.rows) > t.maxRows {
+		return t.maxRows
+	}
+	return len(t.rows)
+}
 
-			t.writer.Write(buf.Bytes())
-			buf.Reset()
+// writeBody writes all rows and markers.
+// first means no line is needed above the first element.
+func (t *Table) writeBody(buf *bytes.Buffer, style *TableStyle, first bool) {
+	t.prevRow = nil
+	t.afterSeparator = false
+	t.rowNumber = t.rowOffset
+	t.nOmitted = 0
+	for j, _row := range t.rows {
+		if t.maxRows > 0 && j == t.maxRows {
+			t.nOmitted = len(t.rows) - j
+			return
 		}
 
-		return nil
+		for _, m := range t.markers[j] {
+			t.writeMarker(buf, style, m, first)
+			first = false
+		}
+
+		t.writeDataRow(buf, style, _row, first)
+		first = false
 	}
 
-	// ------------------------------------------------
+	// markers after the last row, which are dropped if the number of rows reaches
+	// the limit, just like in streaming mode.
+	if t.maxRows > 0 && len(t.rows) >= t.maxRows {
+		return
+	}
+	ms := t.markers[len(t.rows)]
+	for len(ms) > 0 && ms[len(ms)-1].separator { // no rows to separate from
+		ms = ms[:len(ms)-1]
+	}
+	for _, m := range ms {
+		t.writeMarker(buf, style, m, first)
+		first = false
+	}
+}
 
-	if len(t.rows) == t.bufRows {
-		// determine the minWidth and maxWidth
-		t.checkWidths()
+// writeDataRow writes a data row and the line above it,
+// first means it is the first element after the header.
+func (t *Table) writeDataRow(buf *bytes.Buffer, style *TableStyle, row []string, first bool) {
+	if t.afterSeparator {
+		first = true
+		t.afterSeparator = false
+	}
+	t.rowNumber++
 
-		_row, err := t.checkRow(row)
-		if err != nil {
-			return err
+	// repeat the header
+	if t.repeatHeader > 0 && t.hasHeader && t.rowNumber > 1 && (t.rowNumber-1)%t.repeatHeader == 0 {
+		if style.LineBetweenRows.Visible() && !first {
+			t.writeLine(buf, style, style.LineBetweenRows)
 		}
-		t.rows = append(t.rows, _row)
-		t.dataAdded = true
+		t.writeHeader(buf, style)
+		first = true
+		t.prevRow = nil
+	}
+
+	if t.rowNumbers {
+		n := strconv.Itoa(t.rowNumber)
+		// keep the last digits, which still tell the rows apart
+		if M := t.maxWidths[0]; t.bufRowsDumped && len(n) > M {
+			mark := t.frozenMark()
+			if w := M - t.strWidth(mark); w > 0 {
+				n = mark + n[len(n)-w:]
+			} else {
+				n = n[len(n)-M:]
+			}
+		}
+		row = append([]string{n}, row...)
+	}
 
-		// write the top line
-		if style.LineTop.Visible() {
-			buf.WriteString(style.LineTop.Begin)
-			for i, M := range t.maxWidths {
-				slice[i] = strings.Repeat(style.LineTop.Hline, M+lenPad2)
+	// merge cells identical to the ones above
+	var hasMerged bool
+	if t.prevRow != nil {
+		if len(t.merged) != len(row) {
+			t.merged = make([]bool, len(row))
+		}
+		for i, c := range t.rcolumns {
+			t.merged[i] = c.MergeCells && row[i] == t.prevRow[i]
+			if t.merged[i] {
+				hasMerged = true
 			}
-			buf.WriteString(strings.Join(slice, style.LineTop.Sep))
-			buf.WriteString(style.LineTop.End)
-			buf.WriteString("\n")
+		}
+	}
+	t.prevRow = row
 
-			t.writer.Write(buf.Bytes())
-			buf.Reset()
+	// line between rows
+	if style.LineBetweenRows.Visible() && !first {
+		if hasMerged {
+			t.writeMergedLine(buf, style, style.LineBetweenRows, t.merged)
+		} else {
+			t.writeLine(buf, style, style.LineBetweenRows)
 		}
+	}
 
-		// write the header
-		if t.hasHeader {
-			_row := make([]string, t.nColumns)
-			for i, c := range t.columns {
-				_row[i] = c.Header
+	// data row
+	if hasMerged {
+		_row := make([]string, len(row))
+		for i, c := range row {
+			if !t.merged[i] {
+				_row[i] = c
 			}
-			wrapped = t.formatRow(_row)
-			if wrapped {
-				for _, row2 = range t.wrappedRow {
-					buf.WriteString(style.HeaderRow.Begin)
-					for i, M := range t.maxWidths {
-						slice[i] = style.Padding + t.formatCell((*row2)[i], M, t.columns[i].Align) + style.Padding
-					}
-					buf.WriteString(strings.Join(slice, style.HeaderRow.Sep))
-					buf.WriteString(style.HeaderRow.End)
-					buf.WriteString("\n")
+		}
+		row = _row
+	}
+	if t.zebra == nil || t.rowNumber&1 == 1 {
+		t.writeRow(buf, style, style.DataRow, row)
+		return
+	}
 
-					t.writer.Write(buf.Bytes())
-					buf.Reset()
+	// decorate all lines of the row
+	start := buf.Len()
+	t.writeRow(buf, style, style.DataRow, row)
+	lines := strings.Split(strings.TrimSuffix(string(buf.Bytes()[start:]), "\n"), "\n")
+	buf.Truncate(start)
+	for _, line := range lines {
+		buf.WriteString(t.zebra(line))
+		buf.WriteString("\n")
+	}
+}
 
-					t.poolSlice.Put(row2)
-				}
-			} else {
-				buf.WriteString(style.HeaderRow.Begin)
-				for i, M := range t.maxWidths {
-					slice[i] = style.Padding + t.formatCell(_row[i], M, t.columns[i].Align) + style.Padding
-				}
-				buf.WriteString(strings.Join(slice, style.HeaderRow.Sep))
-				buf.WriteString(style.HeaderRow.End)
-				buf.WriteString("\n")
+// writeMergedLine writes a horizontal line, where segments of merged cells are blank.
+func (t *Table) writeMergedLine(buf *bytes.Buffer, style *TableStyle, line LineStyle, merged []bool) {
+	slice := t.cellSlice()
+	lenPads := t.padWidth(style)
 
-				t.writer.Write(buf.Bytes())
-				buf.Reset()
-			}
+	if merged[0] {
+		buf.WriteString(style.DataRow.Begin)
+	} else {
+		buf.WriteString(line.Begin)
+	}
+	for i, M := range t.maxWidths {
+		if merged[i] {
+			slice[i] = strings.Repeat(" ", M+lenPads)
+		} else {
+			slice[i] = strings.Repeat(line.Hline, M+lenPads)
+		}
+	}
+	buf.WriteString(strings.Join(slice, line.Sep))
+	if merged[len(merged)-1] {
+		t.endLine(buf, style.DataRow.End)
+	} else {
+		t.endLine(buf, line.End)
+	}
+}
 
-			// line belowHeader
-			if style.LineBelowHeader.Visible() {
-				buf.WriteString(style.LineBelowHeader.Begin)
-				for i, M := range t.maxWidths {
-					slice[i] = strings.Repeat(style.LineBelowHeader.Hline, M+lenPad2)
-				}
-				buf.WriteString(strings.Join(slice, style.LineBelowHeader.Sep))
-				buf.WriteString(style.LineBelowHeader.End)
-				buf.WriteString("\n")
+// writeMarker writes a marker, first means it is the first element after the header.
+func (t *Table) writeMarker(buf *bytes.Buffer, style *TableStyle, m marker, first bool) {
+	if m.separator {
+		t.writeSeparator(buf, style, first)
+		return
+	}
+	if m.cells != nil {
+		t.writeCellsMarker(buf, style, m.cells, first)
+		return
+	}
+	if t.afterSeparator {
+		first = true
+		t.afterSeparator = false
+	}
 
-				t.writer.Write(buf.Bytes())
-				buf.Reset()
-			}
+	t.prevRow = nil
+	t.rowNumber += m.skip
+
+	// line between rows
+	if style.LineBetweenRows.Visible() && !first {
+		t.writeLine(buf, style, style.LineBetweenRows)
+	}
+
+	t.writeSpan(buf, style, style.DataRow, m.label, AlignCenter)
+}
+
+// writeCellsMarker writes a non-data row, first means it is the first element after the header.
+func (t *Table) writeCellsMarker(buf *bytes.Buffer, style *TableStyle, cells []string, first bool) {
+	if t.afterSeparator {
+		first = true
+		t.afterSeparator = false
+	}
+	t.prevRow = nil
+
+	if style.LineBetweenRows.Visible() && !first {
+		t.writeLine(buf, style, style.LineBetweenRows)
+	}
+	if t.rowNumbers {
+		cells = append([]string{""}, cells...)
+	}
+	t.writeRow(buf, style, style.DataRow, cells)
+}
+
+// writePendingSeparator writes the separator held back in streaming mode.
+func (t *Table) writePendingSeparator(buf *bytes.Buffer, style *TableStyle) {
+	if t.sepPending {
+		t.sepPending = false
+		t.writeSeparator(buf, style, false)
+	}
+}
+
+// writeSeparator writes a separator line, first means it is the first element after the header.
+func (t *Table) writeSeparator(buf *bytes.Buffer, style *TableStyle, first bool) {
+	if t.afterSeparator {
+		return
+	}
+	t.afterSeparator = true // the following element is treated as the first one
+	t.prevRow = nil
+	if first {
+		return
+	}
+
+	line, other := style.LineBetweenRows, style.LineBelowHeader
+	if t.emphasizeSeparators {
+		line, other = other, line
+	}
+	if !line.Visible() {
+		line = other
+	}
+	if !line.Visible() { // a dashed line matching column widths
+		line = LineStyle{
+			Begin: strings.Repeat(" ", t.strWidth(style.DataRow.Begin)),
+			Hline: "-",
+			Sep:   strings.Repeat(" ", t.strWidth(style.DataRow.Sep)),
 		}
+	}
+	t.writeLine(buf, style, line)
+}
 
-		// write the rows
-		hasLineBetweenRows := style.LineBetweenRows.Visible()
-		for j, _row := range t.rows {
-			// line between rows
-			if hasLineBetweenRows && j > 0 {
-				buf.WriteString(style.LineBetweenRows.Begin)
-				for i, M := range t.maxWidths {
-					slice[i] = strings.Repeat(style.LineBetweenRows.Hline, M+lenPad2)
-				}
-				buf.WriteString(strings.Join(slice, style.LineBetweenRows.Sep))
-				buf.WriteString(style.LineBetweenRows.End)
-				buf.WriteString("\n")
+// writeSpan writes a text as a single cell spanning all columns,
+// the text is wrapped if it is longer than the table width, and each line of
+// a multi-line text is written separately, unless newlines are replaced, see SingleLine.
+func (t *Table) writeSpan(buf *bytes.Buffer, style *TableStyle, rowStyle RowStyle, text string, align Align) {
+	padLeft, padRight := t.pads(style)
+	width := t.tableWidth(style) - t.strWidth(rowStyle.Begin) - t.strWidth(rowStyle.End) -
+		t.padWidth(style)
+	if width < 1 {
+		width = 1
+	}
 
-				t.writer.Write(buf.Bytes())
-				buf.Reset()
+	text = t.sanitizeCell(text)
+	var lines []string
+	if t.joinLines() { // one line, which is clipped below
+		lines = []string{t.joinCellLines(text)}
+	} else {
+		for _, line := range strings.Split(text, "\n") {
+			if t.width(line) <= width {
+				lines = append(lines, line)
+				continue
 			}
+			lines = t.wrap(lines, line, width, " ", 0)
+		}
+	}
 
-			// data row
-			wrapped = t.formatRow(_row)
-			if wrapped {
-				for _, row2 = range t.wrappedRow {
-					buf.WriteString(style.DataRow.Begin)
-					for i, M := range t.maxWidths {
-						slice[i] = style.Padding + t.formatCell((*row2)[i], M, t.columns[i].Align) + style.Padding
-					}
-					buf.WriteString(strings.Join(slice, style.DataRow.Sep))
-					buf.WriteString(style.DataRow.End)
-					buf.WriteString("\n")
+	for _, line := range lines {
+		buf.WriteString(rowStyle.Begin)
+		buf.WriteString(padLeft)
+		// a wide character not fitting in a narrow table is dropped
+		line = t.fit(strings.TrimRight(line, " "), width)
+		buf.WriteString(alignText(line, t.width(line), width, align))
+		buf.WriteString(padRight)
+		t.endLine(buf, rowStyle.End)
+	}
+}
 
-					t.writer.Write(buf.Bytes())
-					buf.Reset()
+// writeLine writes a horizontal line with the given line style.
+func (t *Table) writeLine(buf *bytes.Buffer, style *TableStyle, line LineStyle) {
+	slice := t.cellSlice()
+	lenPads := t.padWidth(style)
 
-					t.poolSlice.Put(row2)
-				}
-			} else {
-				buf.WriteString(style.DataRow.Begin)
-				for i, M := range t.maxWidths {
-					slice[i] = style.Padding + t.formatCell(_row[i], M, t.columns[i].Align) + style.Padding
-				}
-				buf.WriteString(strings.Join(slice, style.DataRow.Sep))
-				buf.WriteString(style.DataRow.End)
-				buf.WriteString("\n")
+	buf.WriteString(line.Begin)
+	for i, M := range t.maxWidths {
+		slice[i] = strings.Repeat(line.Hline, M+lenPads)
+	}
+	buf.WriteString(strings.Join(slice, line.Sep))
+	t.endLine(buf, line.End)
+}
 
-				t.writer.Write(buf.Bytes())
-				buf.Reset()
+// writeRow writes a row with the given row style,
+// the row might be wrapped into multiple lines.
+func (t *Table) writeRow(buf *bytes.Buffer, style *TableStyle, rowStyle RowStyle, row []string) {
+	if t.joinLines() {
+		var joined []string
+		for i, c := range row {
+			if strings.IndexByte(c, '\n') < 0 {
+				continue
+			}
+			if joined == nil { // rows are not modified
+				joined = make([]string, len(row))
+				copy(joined, row)
 			}
+			joined[i] = t.joinCellLines(c)
 		}
+		if joined != nil {
+			row = joined
+		}
+	}
+	if t.formatRow(row) {
+		for _, row2 := range t.wrappedRow {
+			t.writeCells(buf, style, rowStyle, *row2)
 
-		t.bufRowsDumped = true
+			t.poolSlice.Put(row2)
+		}
+		return
 	}
+	t.writeCells(buf, style, rowStyle, row)
+}
 
-	return nil
+// writeCells writes one line of cells which have been wrapped or clipped.
+func (t *Table) writeCells(buf *bytes.Buffer, style *TableStyle, rowStyle RowStyle, cells []string) {
+	slice := t.cellSlice()
+	padLeft, padRight := t.pads(style)
+
+	buf.WriteString(rowStyle.Begin)
+	for i, M := range t.maxWidths {
+		slice[i] = padLeft + t.formatCell(cells[i], M, t.rcolumns[i].Align) + padRight
+	}
+	buf.WriteString(strings.Join(slice, rowStyle.Sep))
+	t.endLine(buf, rowStyle.End)
+}
+
+// writeHead writes the top line, the header row and the line below the header.
+func (t *Table) writeHead(buf *bytes.Buffer, style *TableStyle) {
+	// write the top line
+	if style.LineTop.Visible() {
+		t.writeLine(buf, style, style.LineTop)
+	}
+
+	if !t.hasHeader {
+		return
+	}
+
+	t.writeHeader(buf, style)
+}
+
+// writeHeader writes the header row and the line below it.
+func (t *Table) writeHeader(buf *bytes.Buffer, style *TableStyle) {
+	_row := make([]string, len(t.rcolumns))
+	for i, c := range t.rcolumns {
+		_row[i] = c.Header
+	}
+	t.writeRow(buf, style, style.HeaderRow, _row)
+
+	// line belowHeader, which is not needed for a header-only table,
+	// where the bottom line follows.
+	if style.LineBelowHeader.Visible() && (len(t.rows) > 0 || len(t.markers) > 0 || t.nStreamed > 0) {
+		t.writeLine(buf, style, style.LineBelowHeader)
+	}
+}
+
+// writeTail writes the summary row, the footer row, the bottom line and the caption.
+func (t *Table) writeTail(buf *bytes.Buffer, style *TableStyle) {
+	if t.nOmitted > 0 {
+		label := fmt.Sprintf("… and %s more rows", humanize.Comma(int64(t.nOmitted)))
+		if t.nOmitted == 1 {
+			label = "… and 1 more row"
+		}
+		t.writeMarker(buf, style, marker{label: label}, false)
+	}
+
+	t.writeSummary(buf, style)
+	t.writeFooter(buf, style)
+
+	if style.LineBottom.Visible() {
+		t.writeLine(buf, style, style.LineBottom)
+	}
+
+	if t.caption == "" {
+		return
+	}
+	width := t.tableWidth(style)
+	var lines []string
+	for _, line := range strings.Split(t.sanitizeCell(t.caption), "\n") {
+		if width <= 0 || t.width(line) <= width {
+			lines = append(lines, line)
+			continue
+		}
+		lines = t.wrap(lines, line, width, " ", 0)
+	}
+	for _, line := range lines {
+		buf.WriteString(strings.TrimRight(line, " "))
+		buf.WriteString("\n")
+	}
+}
+
+// tableWidth returns the display width of a data line.
+func (t *Table) tableWidth(style *TableStyle) int {
+	lenPads := t.padWidth(style)
+	w := t.strWidth(style.DataRow.Begin) + t.strWidth(style.DataRow.End)
+	for _, M := range t.maxWidths {
+		w += M + lenPads
+	}
+	if len(t.maxWidths) > 1 {
+		w += t.strWidth(style.DataRow.Sep) * (len(t.maxWidths) - 1)
+	}
+	return w
+}
+
+// cellSlice returns the reused slice for joining cells of each line.
+func (t *Table) cellSlice() []string {
+	if len(t.slice) != len(t.maxWidths) {
+		t.slice = make([]string, len(t.maxWidths))
+	}
+	return t.slice
 }
 
 // formatRow wraps or clips cells.
@@ -572,8 +1935,10 @@ func (t *Table) formatRow(row []string) bool {
 	// -------------------------------------------------------------
 	// initialize some data structures
 
-	if t.rotate == nil {
-		t.rotate = make([][]string, t.nColumns)
+	n := len(row)
+
+	if len(t.rotate) != n {
+		t.rotate = make([][]string, n)
 		for i := range t.rotate {
 			t.rotate[i] = make([]string, 0, 8)
 		}
@@ -589,22 +1954,23 @@ func (t *Table) formatRow(row []string) bool {
 		t.wrappedRow = t.wrappedRow[:0]
 	}
 
-	if t.poolSlice == nil {
+	if t.poolSlice == nil || t.poolSize != n {
+		t.poolSize = n
 		t.poolSlice = &sync.Pool{New: func() interface{} {
-			tmp := make([]string, t.nColumns)
+			tmp := make([]string, n)
 			return &tmp
 		}}
 	}
 
-	if t.wrapDelimiter == 0 {
-		t.wrapDelimiter = ' '
+	if t.wrapDelimiters == "" {
+		t.wrapDelimiters = " "
 	}
 
 	// -------------------------------------------------------------
 
 	var needWrap = false
 	for i, c := range row {
-		if len(c) > t.maxWidths[i] {
+		if t.width(c) > t.maxWidths[i] || strings.IndexByte(c, '\n') >= 0 {
 			needWrap = true
 		}
 	}
@@ -615,15 +1981,10 @@ func (t *Table) formatRow(row []string) bool {
 	// -------------------------------------------------------------
 
 	var maxWidth int
-	var w int
-	var r rune
 
 	var i, j int
 	var cell string
-	var workingLine string
-	var spacePos charPos
-	var lastPos charPos
-	lenClipMark := len(t.clipMark)
+	lenClipMark := t.strWidth(t.clipMark)
 	for i, cell = range row {
 		maxWidth = t.maxWidths[i]
 
@@ -631,102 +1992,275 @@ func (t *Table) formatRow(row []string) bool {
 			maxWidth = t.minWidth
 		}
 
-		if len(cell) <= maxWidth {
-			t.rotate[i] = append(t.rotate[i], cell)
-			continue
+		// each line of a multi-line cell is formatted independently
+		for _, line := range strings.Split(cell, "\n") {
+			if t.width(line) <= maxWidth {
+				t.rotate[i] = append(t.rotate[i], line)
+				continue
+			}
+
+			// ---------------------------------------------------
+			// clip
+
+			if t.clipCell {
+				mark := t.clipMark
+				if lenClipMark > maxWidth { // the mark is dropped only for this cell
+					mark = ""
+				}
+				var clipped string
+				if t.clipAtWord || (i < len(t.rcolumns) && t.rcolumns[i].ClipAtWord) {
+					clipped = t.truncateAtWord(line, maxWidth, mark)
+				} else {
+					clipped = t.truncate(line, maxWidth, mark)
+				}
+				// the mark or a wide character at the boundary might not fit,
+				// while a narrower result is padded by formatCell.
+				if mark != "" && t.width(clipped) > maxWidth {
+					clipped = t.truncate(line, maxWidth, "")
+				}
+				t.rotate[i] = append(t.rotate[i], t.fit(clipped, maxWidth))
+				continue
+			}
+
+			// ---------------------------------------------------
+			// wrap
+
+			k := len(t.rotate[i])
+			t.rotate[i] = t.wrap(t.rotate[i], line, maxWidth, t.wrapDelimiters, t.hyphen)
+			for ; k < len(t.rotate[i]); k++ {
+				t.rotate[i][k] = t.fit(t.rotate[i][k], maxWidth)
+			}
 		}
+	}
 
-		// ---------------------------------------------------
-		// clip
+	var maxRow int
+	for _, tmp := range t.rotate {
+		if len(tmp) > maxRow {
+			maxRow = len(tmp)
+		}
+	}
 
-		if t.clipCell && len(cell) > maxWidth {
-			if lenClipMark > maxWidth {
-				t.clipMark = ""
-				lenClipMark = len(t.clipMark)
+	// vertical alignment, i.e., the number of empty lines above each cell
+	if len(t.offsets) != n {
+		t.offsets = make([]int, n)
+	}
+	var valign VAlign
+	for i = 0; i < n; i++ {
+		valign = t.valign
+		if i < len(t.rcolumns) && t.rcolumns[i].VAlign > 0 {
+			valign = t.rcolumns[i].VAlign
+		}
+		switch valign {
+		case VAlignMiddle:
+			t.offsets[i] = (maxRow - len(t.rotate[i])) / 2
+		case VAlignBottom:
+			t.offsets[i] = maxRow - len(t.rotate[i])
+		default:
+			t.offsets[i] = 0
+		}
+	}
+
+	var row2 *[]string
+	var k int
+	for j = 0; j < maxRow; j++ {
+		row2 = t.poolSlice.Get().(*[]string)
+		for i = 0; i < n; i++ {
+			k = j - t.offsets[i]
+			if k < 0 || k+1 > len(t.rotate[i]) {
+				(*row2)[i] = ""
+			} else {
+				(*row2)[i] = t.rotate[i][k]
 			}
-			t.rotate[i] = append(t.rotate[i], runewidth.Truncate(cell, maxWidth, t.clipMark))
-			continue
 		}
+		t.wrappedRow = append(t.wrappedRow, row2)
+	}
+
+	return true
+}
+
+// fit makes sure a line of a formatted cell is not wider than the column,
+// which happens for a single wide character in a narrow column,
+// e.g., a column of which the width is determined by the buffered rows in streaming mode.
+// Such a character is dropped, as a line longer than the column breaks the borders.
+func (t *Table) fit(s string, maxWidth int) string {
+	if t.width(s) <= maxWidth {
+		return s
+	}
+	s = t.truncate(s, maxWidth, "")
+	if t.width(s) > maxWidth {
+		return ""
+	}
+	return s
+}
+
+// frozenMark returns the mark for clipping numbers which should not be wrapped
+// into multiple lines in a column with a frozen width in streaming mode.
+func (t *Table) frozenMark() string {
+	if t.clipMark == "" {
+		return "…"
+	}
+	return t.clipMark
+}
+
+// truncateAtWord clips a cell at a word boundary, see ClipAtWord.
+func (t *Table) truncateAtWord(s string, maxWidth int, tail string) string {
+	clipped := t.truncate(s, maxWidth, tail)
+	prefix := strings.TrimSuffix(clipped, tail)
+	if !strings.HasPrefix(s, prefix) { // ANSI escape sequences are added
+		return clipped
+	}
 
-		// ---------------------------------------------------
-		// wrap
+	// already at a boundary
+	next, _ := utf8.DecodeRuneInString(s[len(prefix):])
+	last, _ := utf8.DecodeLastRuneInString(prefix)
+	if strings.ContainsRune(t.wrapDelimiters, next) || t.runeWidth(next) > 1 || t.runeWidth(last) > 1 {
+		return clipped
+	}
+
+	k := strings.LastIndexAny(prefix, t.wrapDelimiters)
+	if k < 0 {
+		return clipped
+	}
+	_, size := utf8.DecodeRuneInString(prefix[k:])
+	kept := strings.TrimRight(prefix[:k+size], " ")
+	if t.strWidth(prefix)-t.strWidth(kept) > maxWidth/2 {
+		return clipped
+	}
+	return kept + tail
+}
 
-		// modify from https://github.com/donatj/wordwrap
+// wrapText wraps a text into lines no wider than maxWidth (display width),
+// preferring to break after any of the delimiters. The lines are appended to the given slice.
+// If hyphen is not 0, it is appended to lines broken in the middle of a word.
+func wrapText(lines []string, text string, maxWidth int, delimiters string, hyphen rune) ([]string, error) {
+	return wrapTextFunc(lines, text, maxWidth, delimiters, hyphen, runewidth.StringWidth)
+}
 
-		workingLine = ""
-		spacePos.pos = 0
-		spacePos.size = 0
-		lastPos.pos = 0
-		lastPos.size = 0
+// ErrCutCharacter means a line of a wrapped cell can not fit in the width without cutting a character.
+var ErrCutCharacter = fmt.Errorf("stable: attempted to cut a character in wrapping")
+
+// wrapTextFunc is the same as wrapText, with a custom function measuring the width of grapheme clusters.
+// Grapheme clusters, e.g., emoji with ZWJ sequences, flags, and characters with combining marks,
+// are never split, and lines are always cut at boundaries of them.
+// ErrCutCharacter is returned if a line still exceeds maxWidth, which should not happen.
+func wrapTextFunc(lines []string, text string, maxWidth int, delimiters string, hyphen rune, strWidth func(string) int) ([]string, error) {
+	// modify from https://github.com/donatj/wordwrap
+
+	var workingLine string
+	var width int // display width of the working line
+	var spacePos charPos
+	var lastPos charPos
 
-		for _, r = range cell {
-			w = utf8.RuneLen(r)
+	var hyphenWidth int
+	if hyphen != 0 {
+		hyphenWidth = strWidth(string(hyphen))
+	}
+	if hyphen == 0 || hyphenWidth >= maxWidth { // no room for the hyphen
+		hyphen = 0
+	}
 
-			workingLine += string(r)
+	var err error
+	var cluster, rest string
+	state := -1
+	for rest = text; rest != ""; {
+		cluster, rest, _, state = uniseg.FirstGraphemeClusterInString(rest, state)
+		workingLine += cluster
+		width += strWidth(cluster)
+
+		if isDelimiter(cluster, delimiters) {
+			spacePos.pos = len(workingLine)
+			spacePos.width = width
+			spacePos.size = len(cluster)
+		}
 
-			if r == t.wrapDelimiter {
-				spacePos.pos = len(workingLine)
-				spacePos.size = w
+		if hyphen != 0 && width >= maxWidth && spacePos.size == 0 {
+			// the word ends here, no need to break it
+			next, _, _, _ := uniseg.FirstGraphemeClusterInString(rest, -1)
+			if width == maxWidth && (rest == "" || isDelimiter(next, delimiters)) {
+				lines = append(lines, workingLine)
+				workingLine = ""
+				width = 0
+			} else { // break the word with a hyphen
+				var cut, w int
+				var c, r string
+				st := -1
+				for r = workingLine; r != ""; {
+					c, r, _, st = uniseg.FirstGraphemeClusterInString(r, st)
+					if w+strWidth(c) > maxWidth-hyphenWidth {
+						break
+					}
+					cut += len(c)
+					w += strWidth(c)
+				}
+				if cut > 0 {
+					lines = append(lines, workingLine[:cut]+string(hyphen))
+					workingLine = workingLine[cut:]
+					width -= w
+				} else { // a single wide character
+					lines = append(lines, workingLine)
+					workingLine = ""
+					width = 0
+				}
 			}
+			lastPos.pos = len(workingLine)
+			lastPos.width = width
+			continue
+		}
 
-			if len(workingLine) >= maxWidth {
-				if spacePos.size > 0 {
-					t.rotate[i] = append(t.rotate[i], workingLine[0:spacePos.pos])
+		if width >= maxWidth {
+			if spacePos.size > 0 && spacePos.width <= maxWidth { // a wide delimiter might exceed the width
+				lines = append(lines, workingLine[0:spacePos.pos])
+
+				workingLine = workingLine[spacePos.pos:]
+				width -= spacePos.width
+			} else if width > maxWidth && lastPos.pos > 0 {
+				lines = append(lines, workingLine[0:lastPos.pos])
+				workingLine = workingLine[lastPos.pos:]
+				width -= lastPos.width
+			} else { // a single wide character is kept even if maxWidth is 1
+				lines = append(lines, workingLine)
+				workingLine = ""
+				width = 0
+			}
 
-					workingLine = workingLine[spacePos.pos:]
-				} else {
-					if len(workingLine) > maxWidth {
-						t.rotate[i] = append(t.rotate[i], workingLine[0:lastPos.pos])
-						workingLine = workingLine[lastPos.pos:]
-					} else {
-						t.rotate[i] = append(t.rotate[i], workingLine)
-						workingLine = ""
-					}
+			if last := lines[len(lines)-1]; uniseg.GraphemeClusterCount(last) > 1 {
+				var w int
+				var c string
+				st := -1
+				for last != "" {
+					c, last, _, st = uniseg.FirstGraphemeClusterInString(last, st)
+					w += strWidth(c)
 				}
-
-				if len(t.rotate[i][len(t.rotate[i])-1]) > maxWidth {
-					panic("attempted to cut character")
+				if w > maxWidth && err == nil {
+					err = ErrCutCharacter
 				}
-
-				spacePos.pos = 0
-				spacePos.size = 0
 			}
 
-			lastPos.pos = len(workingLine)
-			lastPos.size = w
+			spacePos.pos = 0
+			spacePos.width = 0
+			spacePos.size = 0
 		}
 
-		if workingLine != "" {
-			t.rotate[i] = append(t.rotate[i], workingLine)
-		}
+		lastPos.pos = len(workingLine)
+		lastPos.width = width
 	}
 
-	var maxRow int
-	for _, tmp := range t.rotate {
-		if len(tmp) > maxRow {
-			maxRow = len(tmp)
-		}
+	if workingLine != "" {
+		lines = append(lines, workingLine)
 	}
 
-	var row2 *[]string
-
-	for j = 0; j < maxRow; j++ {
-		row2 = t.poolSlice.Get().(*[]string)
-		for i = 0; i < t.nColumns; i++ {
-			if j+1 > len(t.rotate[i]) {
-				(*row2)[i] = ""
-			} else {
-				(*row2)[i] = t.rotate[i][j]
-			}
-		}
-		t.wrappedRow = append(t.wrappedRow, row2)
-	}
+	return lines, err
+}
 
-	return true
+// isDelimiter checks if a grapheme cluster is one of the delimiters.
+func isDelimiter(cluster string, delimiters string) bool {
+	r, size := utf8.DecodeRuneInString(cluster)
+	return size > 0 && size == len(cluster) && strings.ContainsRune(delimiters, r)
 }
 
+// charPos is a position in a line, pos is the byte offset, and width is the display width before it.
 type charPos struct {
-	pos, size int
+	pos, width, size int
 }
 
 // formatCell formats a cell with given width and text alignment.
@@ -736,7 +2270,11 @@ func (t *Table) formatCell(text string, width int, align Align) string {
 		a = t.align
 	}
 
-	lenText := runewidth.StringWidth(text)
+	return alignText(text, t.width(text), width, a)
+}
+
+// alignText pads a text of the display width lenText to the given width according to the alignment.
+func alignText(text string, lenText int, width int, a Align) string {
 
 	// here, width need to be >= len(text)
 	if lenText > width {
@@ -759,7 +2297,17 @@ func (t *Table) formatCell(text string, width int, align Align) string {
 }
 
 // Render render all data with give style.
+// A table with only a header is rendered with widths determined by the header,
+// while an empty table without header and rows is rendered as nothing.
+// Use RenderE() to check errors like ErrTableTooWide.
 func (t *Table) Render(style *TableStyle) []byte {
+	data, _ := t.render(style)
+	return data
+}
+
+// render renders all data with give style, and returns the error of
+// this rendering like ErrTableTooWide, which is not recorded in the table.
+func (t *Table) render(style *TableStyle) ([]byte, error) {
 	if style == nil { // the argument not given
 		style = t.style
 	}
@@ -770,128 +2318,312 @@ func (t *Table) Render(style *TableStyle) []byte {
 	buf := t.buf
 	buf.Reset()
 
-	if t.slice == nil {
-		t.slice = make([]string, t.nColumns)
+	// an empty table, neither header nor rows are added
+	if t.nColumns == 0 {
+		return buf.Bytes(), nil
 	}
-	slice := t.slice
 
-	lenPad2 := len(style.Padding) * 2
-	var wrapped bool
+	if t.groupBy {
+		return t.renderGroups(style)
+	}
 
-	// determine the minWidth and maxWidth
-	t.checkWidths()
+	// determine the minWidth and maxWidth, which are kept until any change
+	if !t.widthsChecked {
+		t.checkWidths()
+	}
+	err := t.fitWidths(style)
 
-	// write the top line
-	if style.LineTop.Visible() {
-		buf.WriteString(style.LineTop.Begin)
-		for i, M := range t.maxWidths {
-			slice[i] = strings.Repeat(style.LineTop.Hline, M+lenPad2)
+	// write the top line and the header
+	t.writeHead(&buf, style)
+
+	// write the rows
+	t.writeBody(&buf, style, true)
+
+	// bottom line
+	t.writeTail(&buf, style)
+
+	return t.prefixLines(buf.Bytes()), err
+}
+
+// Filter returns a view of the table which only contains rows for which keep returns true.
+// The view shares the configuration (header, columns, and options) with the table,
+// while the rows of the table are left untouched, so multiple filters can be
+// rendered from one table. Column widths of the view are determined by the kept rows.
+// The view is always an in-memory table, i.e., the writer is not inherited,
+// so it is only meaningful for buffered rows in streaming mode.
+func (t *Table) Filter(keep func(row []string) bool) *Table {
+	rows := make([][]string, 0, len(t.rows))
+	var rawRows [][]interface{}
+	for i, row := range t.rows {
+		if keep(row) {
+			rows = append(rows, row)
+			if t.keepRaw {
+				rawRows = append(rawRows, t.rawRows[i])
+			}
 		}
-		buf.WriteString(strings.Join(slice, style.LineTop.Sep))
-		buf.WriteString(style.LineTop.End)
-		buf.WriteString("\n")
 	}
+	v := t.view(rows)
+	v.rawRows = rawRows
+	return v
+}
 
-	// write the header
-	var row2 *[]string
-	if t.hasHeader {
-		_row := make([]string, t.nColumns)
-		for i, c := range t.columns {
-			_row[i] = c.Header
-		}
-		wrapped = t.formatRow(_row)
-		if wrapped {
-			for _, row2 = range t.wrappedRow {
-				buf.WriteString(style.HeaderRow.Begin)
-				for i, M := range t.maxWidths {
-					slice[i] = style.Padding + t.formatCell((*row2)[i], M, t.columns[i].Align) + style.Padding
-				}
-				buf.WriteString(strings.Join(slice, style.HeaderRow.Sep))
-				buf.WriteString(style.HeaderRow.End)
-				buf.WriteString("\n")
+// view creates a shallow copy of the table with the given rows.
+// Reused data structures and states of streaming mode are reset.
+func (t *Table) view(rows [][]string) *Table {
+	v := new(Table)
+	*v = *t
+	v.rows = rows
+
+	v.markers = nil
+	v.pending = nil
+	v.pendingRaw = nil
+	v.rawRows = nil
+	v.prevRow = nil
+	if t.aggregators != nil {
+		v.aggregators = make([]aggregator, len(t.aggregators))
+	}
+	v.merged = nil
+
+	v.rcolumns = nil
+	v.minWidths = nil
+	v.maxWidths = nil
+	v.natWidths = nil
+	v.widthsChecked = false
+
+	v.slice = nil
+	v.rotate = nil
+	v.offsets = nil
+	v.wrappedRow = nil
+	v.poolSlice = nil
+	v.poolSize = 0
+	v.buf = bytes.Buffer{}
+
+	v.writer = nil
+	v.hasWriter = false
+	v.bufRows = 0
+	v.bufAll = false
+	v.bufRowsDumped = false
+	v.flushed = false
+	return v
+}
 
-				t.poolSlice.Put(row2)
-			}
-		} else {
-			buf.WriteString(style.HeaderRow.Begin)
-			for i, M := range t.maxWidths {
-				slice[i] = style.Padding + t.formatCell(_row[i], M, t.columns[i].Align) + style.Padding
-			}
-			buf.WriteString(strings.Join(slice, style.HeaderRow.Sep))
-			buf.WriteString(style.HeaderRow.End)
-			buf.WriteString("\n")
+// RenderPreview renders the first head and the last tail rows, with a line
+// spanning all columns in between, saying how many rows are omitted.
+// Column widths are determined by the shown rows.
+// All rows are rendered if head+tail is not smaller than the number of rows.
+func (t *Table) RenderPreview(style *TableStyle, head, tail int) []byte {
+	if head < 0 {
+		head = 0
+	}
+	if tail < 0 {
+		tail = 0
+	}
+	n := len(t.rows)
+	if head+tail >= n {
+		return t.Render(style)
+	}
+
+	rows := make([][]string, 0, head+tail)
+	rows = append(rows, t.rows[:head]...)
+	rows = append(rows, t.rows[n-tail:]...)
+	v := t.view(rows)
+
+	// keep markers of the shown rows
+	v.markers = make(map[int][]marker, len(t.markers)+1)
+	for j, ms := range t.markers {
+		if j <= head {
+			v.markers[j] = append(v.markers[j], ms...)
+		} else if j >= n-tail {
+			v.markers[j-(n-tail)+head] = append(v.markers[j-(n-tail)+head], ms...)
 		}
+	}
+	omitted := n - head - tail
+	v.markers[head] = append(v.markers[head], marker{
+		label: fmt.Sprintf("… %s rows omitted …", humanize.Comma(int64(omitted))),
+		skip:  omitted,
+	})
+
+	return v.Render(style)
+}
+
+// ErrInvalidRowRange means the row range is invalid.
+var ErrInvalidRowRange = fmt.Errorf("stable: invalid row range")
+
+// PageWidths makes RenderRows determine column widths by the rows of the page,
+// instead of all rows of the table.
+func (t *Table) PageWidths() *Table {
+	t.pageWidths = true
+	return t
+}
+
+// SeamlessPages makes RenderRows write the top line and the header only for
+// the first page, and the summary row, the bottom line, and the caption only
+// for the last page, so the pages can be concatenated into the full table.
+// Column widths are determined by all rows in this case, i.e., PageWidths is ignored.
+func (t *Table) SeamlessPages() *Table {
+	t.seamlessPages = true
+	return t
+}
 
-		// line belowHeader
-		if style.LineBelowHeader.Visible() {
-			buf.WriteString(style.LineBelowHeader.Begin)
-			for i, M := range t.maxWidths {
-				slice[i] = strings.Repeat(style.LineBelowHeader.Hline, M+lenPad2)
+// RenderRows renders the header and rows in the range [from, to) (0-based),
+// which is useful for paging through a big table.
+// Column widths are determined by all rows so pages are aligned,
+// unless PageWidths() is called. Row numbers and the summary row are
+// computed over the whole table. Markers placed before these rows are kept,
+// and the ones after the last row of the table are kept for the last page.
+// Indexes out of range are clamped to [0, NumRows()], and an error is
+// returned if from is larger than to. ErrTableTooWide is returned along with
+// the output if the maximum total width can not be satisfied.
+// In streaming mode, only buffered rows are rendered.
+func (t *Table) RenderRows(style *TableStyle, from, to int) ([]byte, error) {
+	if t.nColumns == 0 {
+		return nil, nil
+	}
+
+	n := len(t.rows)
+	if from < 0 {
+		from = 0
+	}
+	if to > n {
+		to = n
+	}
+	if from > to {
+		return nil, fmt.Errorf("%w: [%d, %d)", ErrInvalidRowRange, from, to)
+	}
+
+	if style == nil { // the argument not given
+		style = t.style
+	}
+	if style == nil { // not defined in the object
+		style = StyleGrid
+	}
+
+	// widths and the summary of the whole table
+	if !t.widthsChecked {
+		t.checkWidths()
+	}
+	err := t.fitWidths(style)
+
+	v := t.view(t.rows[from:to])
+	v.maxRows = 0
+	v.rowOffset = from
+	for j, ms := range t.markers {
+		if (j >= from && j < to) || (j == n && to == n) {
+			if v.markers == nil {
+				v.markers = make(map[int][]marker, len(t.markers))
 			}
-			buf.WriteString(strings.Join(slice, style.LineBelowHeader.Sep))
-			buf.WriteString(style.LineBelowHeader.End)
-			buf.WriteString("\n")
+			v.markers[j-from] = ms
 		}
 	}
 
-	// write the rows
-	hasLineBetweenRows := style.LineBetweenRows.Visible()
-	for j, _row := range t.rows {
-		// line between rows
-		if hasLineBetweenRows && j > 0 {
-			buf.WriteString(style.LineBetweenRows.Begin)
-			for i, M := range t.maxWidths {
-				slice[i] = strings.Repeat(style.LineBetweenRows.Hline, M+lenPad2)
-			}
-			buf.WriteString(strings.Join(slice, style.LineBetweenRows.Sep))
-			buf.WriteString(style.LineBetweenRows.End)
-			buf.WriteString("\n")
-		}
-
-		// data row
-		wrapped = t.formatRow(_row)
-		if wrapped {
-			for _, row2 = range t.wrappedRow {
-				buf.WriteString(style.DataRow.Begin)
-				for i, M := range t.maxWidths {
-					slice[i] = style.Padding + t.formatCell((*row2)[i], M, t.columns[i].Align) + style.Padding
-				}
-				buf.WriteString(strings.Join(slice, style.DataRow.Sep))
-				buf.WriteString(style.DataRow.End)
-				buf.WriteString("\n")
+	if t.pageWidths && !t.seamlessPages {
+		v.checkWidths()
+		err = v.fitWidths(style)
+	} else {
+		v.rcolumns = t.rcolumns
+		v.minWidths = t.minWidths
+		v.maxWidths = t.maxWidths
+		v.widthsChecked = true
+	}
+	v.aggregators = t.aggregators
 
-				t.poolSlice.Put(row2)
-			}
-		} else {
-			buf.WriteString(style.DataRow.Begin)
-			for i, M := range t.maxWidths {
-				slice[i] = style.Padding + t.formatCell(_row[i], M, t.columns[i].Align) + style.Padding
-			}
-			buf.WriteString(strings.Join(slice, style.DataRow.Sep))
-			buf.WriteString(style.DataRow.End)
-			buf.WriteString("\n")
+	buf := v.buf
+	if !t.seamlessPages || from == 0 {
+		v.writeHead(&buf, style)
+	}
+	v.writeBody(&buf, style, !t.seamlessPages || from == 0)
+	if !t.seamlessPages || to == n {
+		v.writeTail(&buf, style)
+	}
+	return v.prefixLines(buf.Bytes()), err
+}
+
+// ErrTransposeInStreamingMode means that transposing is not supported in streaming mode.
+var ErrTransposeInStreamingMode = fmt.Errorf("stable: transposing is not supported in streaming mode")
+
+// Transpose returns a new table where columns become rows, which is more readable
+// for tables with a few rows but many columns. The first column holds the
+// original headers (if any), and the following columns are the original rows.
+// The new table has no header.
+// Global options are kept, while column-specific options are reset.
+func (t *Table) Transpose() (*Table, error) {
+	return t.transpose(-1)
+}
+
+// TransposeBy is similar to Transpose, but the values of the given key column
+// are used as the header of the new table, and the key column is not a row.
+func (t *Table) TransposeBy(key string) (*Table, error) {
+	k, err := t.columnIndex(key)
+	if err != nil {
+		return nil, err
+	}
+	return t.transpose(k)
+}
+
+// transpose transposes the table, k is the index of the key column, -1 for none.
+func (t *Table) transpose(k int) (*Table, error) {
+	if t.hasWriter {
+		return nil, ErrTransposeInStreamingMode
+	}
+
+	t2 := New()
+	t2.style = t.style
+	t2.convTable = t.convTable
+	t2.align = t.align
+	t2.valign = t.valign
+	t2.minWidth = t.minWidth
+	t2.maxWidth = t.maxWidth
+	t2.wrapDelimiters = t.wrapDelimiters
+	t2.clipCell = t.clipCell
+	t2.clipMark = t.clipMark
+
+	offset := 0
+	if t.hasHeader {
+		offset = 1
+	}
+	n := len(t.rows) + offset
+	t2.nColumns = n
+	t2.columns = make([]Column, n)
+
+	if k >= 0 {
+		t2.columns[0].Header = t.columns[k].Header
+		for j, row := range t.rows {
+			t2.columns[j+offset].Header = row[k]
 		}
+		t2.hasHeader = true
 	}
 
-	// bottom line
-	if style.LineBottom.Visible() {
-		buf.WriteString(style.LineBottom.Begin)
-		for i, M := range t.maxWidths {
-			slice[i] = strings.Repeat(style.LineBottom.Hline, M+lenPad2)
+	t2.rows = make([][]string, 0, t.nColumns)
+	for i, c := range t.columns {
+		if i == k {
+			continue
 		}
-		buf.WriteString(strings.Join(slice, style.LineBottom.Sep))
-		buf.WriteString(style.LineBottom.End)
-		buf.WriteString("\n")
+		row := make([]string, n)
+		if t.hasHeader {
+			row[0] = c.Header
+		}
+		for j, _row := range t.rows {
+			row[j+offset] = _row[i]
+		}
+		t2.rows = append(t2.rows, row)
 	}
+	t2.dataAdded = len(t2.rows) > 0
 
-	return buf.Bytes()
+	return t2, nil
 }
 
 // ErrNoDataAdded means not data is added. Not used.
 var ErrNoDataAdded = fmt.Errorf("stable: no data added")
 
+// streamRowNumberDigits is the number of digits reserved for row numbers in streaming mode.
+const streamRowNumberDigits = 6
+
 // checkWidths determine the minimum and maximum widths of each column.
+// The widths are frozen after the buffered rows are written in streaming mode.
 func (t *Table) checkWidths() error {
+	if t.bufRowsDumped {
+		return nil
+	}
 	// if t.hasHeader && !t.dataAdded {
 	// 	return ErrNoDataAdded
 	// }
@@ -906,7 +2638,7 @@ func (t *Table) checkWidths() error {
 	var c Column
 	if t.hasHeader {
 		for i, c = range t.columns {
-			l = len(c.Header)
+			l = t.width(c.Header)
 			if l > t.maxWidths[i] {
 				t.maxWidths[i] = l
 			}
@@ -916,10 +2648,57 @@ func (t *Table) checkWidths() error {
 		}
 	}
 
+	rows := t.rows
+	if t.sampleRows > 0 && len(rows) > t.sampleRows {
+		rows = rows[:t.sampleRows]
+	}
 	var v string
-	for _, row := range t.rows {
+	for _, row := range rows {
 		for i, v = range row {
-			l = len(v)
+			l = t.width(v)
+			if l > t.maxWidths[i] {
+				t.maxWidths[i] = l
+			}
+			if l < t.minWidths[i] {
+				t.minWidths[i] = l
+			}
+		}
+	}
+
+	// non-data rows like subtotals
+	for _, ms := range t.markers {
+		for _, m := range ms {
+			for i, v = range m.cells {
+				l = t.width(v)
+				if l > t.maxWidths[i] {
+					t.maxWidths[i] = l
+				}
+				if l < t.minWidths[i] {
+					t.minWidths[i] = l
+				}
+			}
+		}
+	}
+
+	// the footer row
+	for i, v = range t.footer {
+		l = t.width(v)
+		if l > t.maxWidths[i] {
+			t.maxWidths[i] = l
+		}
+		if l < t.minWidths[i] {
+			t.minWidths[i] = l
+		}
+	}
+
+	// the summary row
+	if t.summary != nil {
+		t.resetSummary()
+		for _, row := range t.rows {
+			t.accumulate(row)
+		}
+		for i, v = range t.summaryRow() {
+			l = t.width(v)
 			if l > t.maxWidths[i] {
 				t.maxWidths[i] = l
 			}
@@ -930,6 +2709,11 @@ func (t *Table) checkWidths() error {
 	}
 
 	for i, c := range t.columns {
+		// no header and no rows
+		if t.minWidths[i] == math.MaxInt {
+			t.minWidths[i] = 0
+		}
+
 		// use user-defined global threshold
 		// only if it is larger than the length of the shortest text
 		if t.minWidth > 0 && t.minWidth > t.minWidths[i] {
@@ -958,9 +2742,44 @@ func (t *Table) checkWidths() error {
 			t.maxWidths[i] = t.minWidths[i]
 		}
 
+		// the header is never wrapped or clipped
+		if t.preserveHeader && t.hasHeader {
+			if l = t.width(c.Header); l > t.maxWidths[i] {
+				t.maxWidths[i] = l
+			}
+		}
+
+		// a column with only empty cells, it happens for new columns in flexible mode.
+		if t.maxWidths[i] < 1 {
+			t.maxWidths[i] = 1
+		}
+
 		// fmt.Printf("coloumn %d: min-width: %d, max-width: %d\n",
 		// 	i+1, t.minWidths[i], t.maxWidths[i])
 	}
+
+	t.rcolumns = t.columns
+	if t.rowNumbers {
+		c := Column{Header: t.sanitizeCell(t.rowNumberHeader), Align: AlignRight}
+		n := t.rowOffset + len(t.rows)
+		for _, ms := range t.markers {
+			for _, m := range ms {
+				n += m.skip
+			}
+		}
+		l = len(strconv.Itoa(n))
+		// more rows might be written after the widths are frozen in streaming mode
+		if t.hasWriter && !t.bufAll && !t.flushed && l < streamRowNumberDigits {
+			l = streamRowNumberDigits
+		}
+		if w := t.width(c.Header); t.hasHeader && w > l {
+			l = w
+		}
+		t.rcolumns = append([]Column{c}, t.columns...)
+		t.minWidths = append([]int{l}, t.minWidths...)
+		t.maxWidths = append([]int{l}, t.maxWidths...)
+	}
+	t.natWidths = append([]int(nil), t.maxWidths...)
 	t.widthsChecked = true
 
 	// fmt.Println(t.minWidths)
@@ -977,8 +2796,12 @@ var ErrWriterRepeatedlySet = fmt.Errorf("stable: writer repeatedly set")
 // Writer sets a writer for render the table. The first bufRows rows will
 // be used to determine the maximum width for each cell if they are not defined
 // with MaxWidth(). bufRows should be in range of [1,1M].
-// If bufRows is 0, it keeps all data in buffer.
-// So a newly added row (Addrow()) is formatted and written to the configured writer immediately.
+// If bufRows is 0, it keeps all data in buffer, and the complete table is written
+// by Flush(), with column widths determined by all rows.
+// Otherwise, a newly added row (Addrow()) is formatted and written to the configured writer immediately,
+// with one call of Write() for all lines of the row.
+// Cells of later rows wider than the determined widths are wrapped, or clipped if ClipCell() is called,
+// so the borders are always aligned. A wide character not fitting in a narrow column is dropped.
 // It is memory-effective for a large number of rows.
 // And it is helpful to pipe the data in shell.
 // Do not forget to call Flush() after adding all rows.
@@ -986,22 +2809,212 @@ func (t *Table) Writer(w io.Writer, bufRows uint) error {
 	if t.hasWriter {
 		return ErrWriterRepeatedlySet
 	}
+	return t.setWriter(w, bufRows)
+}
+
+// ErrWriterInUse means the writer can not be replaced or detached, as some data has been written.
+var ErrWriterInUse = fmt.Errorf("stable: the writer can not be changed after data is written")
+
+// SetWriter is similar to Writer, but it can also replace the writer and the number
+// of buffered rows, as long as no data has been written, i.e., the buffered rows
+// are not dumped and Flush() is not called. If more rows than bufRows are already buffered,
+// all of them are used to determine the widths.
+func (t *Table) SetWriter(w io.Writer, bufRows uint) error {
+	if t.hasWriter && (t.bufRowsDumped || t.flushed) {
+		return fmt.Errorf("%w: %d rows written", ErrWriterInUse, len(t.rows)+t.nStreamed)
+	}
+	return t.setWriter(w, bufRows)
+}
+
+// DetachWriter converts the table back to an in-memory table, keeping the buffered rows,
+// as long as no data has been written. It's a no-op if the writer is not set.
+// The adaptive buffering of WriterAdaptive() is also turned off.
+func (t *Table) DetachWriter() error {
+	if !t.hasWriter {
+		return nil
+	}
+	if t.bufRowsDumped || t.flushed {
+		return fmt.Errorf("%w: %d rows written", ErrWriterInUse, len(t.rows)+t.nStreamed)
+	}
+	t.writer = nil
+	t.hasWriter = false
+	t.teeWriters = nil
+	t.bufAll = false
+	t.bufRows = 0
+	t.adaptive = false // a later writer buffers rows as it's set
+	t.nStable, t.bufMaxWidths = 0, nil
+
+	// the held-back row of DedupConsecutive is added, and its run goes on
+	if t.pending != nil {
+		count := t.pendingCount
+		if err := t.commitPending(); err != nil {
+			return err
+		}
+		t.runCount = count
+	}
+	return nil
+}
+
+// HasWriter tells whether a writer is set, i.e., the table is in streaming mode
+// and the data should be written with Flush() instead of Render().
+func (t *Table) HasWriter() bool {
+	return t.hasWriter
+}
+
+func (t *Table) setWriter(w io.Writer, bufRows uint) error {
+	if t.keepRaw {
+		return ErrKeepRawValuesInStreamingMode
+	}
 	t.writer = w
 	t.hasWriter = true
+	t.bufAll = false
+	t.adaptive = false
+	t.chooseStyle()
 	if bufRows == 0 {
 		t.bufAll = true
 		bufRows = 1024
 	} else if bufRows > 1<<20 {
 		bufRows = 1 << 20
 	}
-	t.rows = make([][]string, 0, bufRows)
+	if int(bufRows) < len(t.rows) { // rows buffered before
+		bufRows = uint(len(t.rows))
+	}
+	if t.rows == nil {
+		t.rows = make([][]string, 0, bufRows)
+	}
 	t.bufRows = int(bufRows)
 
 	return nil
 }
 
-// Flush dumps the remaining data.
+// WriterAdaptive is similar to Writer, but the number of buffered rows is adaptive.
+// Rows are buffered until the maximum width of each column has not changed for
+// stableFor consecutive rows, with at least minRows and at most maxRows rows buffered.
+// Then the buffered rows are used to determine the widths and written,
+// and later rows are written immediately.
+func (t *Table) WriterAdaptive(w io.Writer, minRows, maxRows, stableFor uint) error {
+	if t.hasWriter {
+		return ErrWriterRepeatedlySet
+	}
+	if minRows == 0 {
+		minRows = 1
+	}
+	if maxRows < minRows {
+		maxRows = minRows
+	}
+	if err := t.setWriter(w, maxRows); err != nil {
+		return err
+	}
+	t.adaptive = true
+	t.minBufRows = int(minRows)
+	t.stableFor = int(stableFor)
+	t.nStable = 0
+	t.bufMaxWidths = nil
+	return nil
+}
+
+// widthsStable updates the maximum widths of buffered rows with a new row,
+// and tells whether enough rows are buffered in the adaptive mode.
+func (t *Table) widthsStable(row []string) bool {
+	if !t.adaptive {
+		return false
+	}
+	if t.bufMaxWidths == nil { // rows might be added before setting the writer
+		t.bufMaxWidths = make([]int, t.nColumns)
+		for _, _row := range t.rows {
+			t.updateBufMaxWidths(_row)
+		}
+	}
+	if t.updateBufMaxWidths(row) {
+		t.nStable = 0
+	} else {
+		t.nStable++
+	}
+	return len(t.rows)+1 >= t.minBufRows && t.nStable >= t.stableFor
+}
+
+// updateBufMaxWidths updates the maximum widths, and returns true if any of them changes.
+func (t *Table) updateBufMaxWidths(row []string) bool {
+	var changed bool
+	var l int
+	for i, v := range row {
+		if l = t.width(v); l > t.bufMaxWidths[i] {
+			t.bufMaxWidths[i] = l
+			changed = true
+		}
+	}
+	return changed
+}
+
+// Concurrent makes AddRow, AddRowStringSlice, AddRowValues, AddRowsFromSlices,
+// AddSection, AddSeparator, Flush, and NextTable safe for concurrent use,
+// e.g., adding rows from a pool of workers in streaming mode.
+// Rows are added in the order of acquiring the lock. Other methods, like setting options
+// and rendering, are not protected, so they should be called before or after the concurrent adding.
+// It should be called before adding any rows.
+func (t *Table) Concurrent() *Table {
+	if t.mu == nil {
+		t.mu = &sync.Mutex{}
+	}
+	return t
+}
+
+// write writes data to the writer, what describes the data for the error message.
+// The first error is recorded and returned by Err() and FlushE(),
+// and no more data is written after it.
+func (t *Table) write(what string, data []byte) {
+	if t.writeErr != nil {
+		return
+	}
+	n, err := t.writer.Write(data)
+	if n > 0 && n <= len(data) {
+		t.nBytes += int64(n)
+	}
+	if err == nil && n < len(data) {
+		err = io.ErrShortWrite
+	}
+	if err != nil {
+		err = fmt.Errorf("stable: writing %s: %w", what, err)
+		t.writeErr = err
+		if t.err == nil {
+			t.err = err
+		}
+	}
+}
+
+// BytesWritten returns the number of bytes successfully written to the writer,
+// including these of previous tables started by NextTable().
+// For a short write, only the written part is counted.
+func (t *Table) BytesWritten() int64 {
+	if t.mu != nil {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+	}
+	return t.nBytes
+}
+
+// ErrAlreadyFlushed means Flush() is called more than once, the data is written only once.
+var ErrAlreadyFlushed = fmt.Errorf("stable: the table is already flushed")
+
+// Flush dumps the remaining data, and flushes the writer if it has a method Flush() error,
+// e.g., a *bufio.Writer. Calling it again is a no-op, see ErrAlreadyFlushed.
+// Errors of writing are returned by FlushE() and Err().
 func (t *Table) Flush() {
+	t.flush()
+}
+
+// flush dumps the remaining data, ErrAlreadyFlushed is returned if it was called.
+func (t *Table) flush() error {
+	if t.mu != nil {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+	}
+	if t.flushed {
+		return ErrAlreadyFlushed
+	}
+	if err := t.commitPending(); err != nil && t.err == nil {
+		t.err = err
+	}
 	t.flushed = true
 
 	style := t.style
@@ -1009,39 +3022,129 @@ func (t *Table) Flush() {
 		style = StyleGrid
 	}
 
-	buf := t.buf
-	buf.Reset()
+	// ------------------------------------------------
+	// only need to append the bottown line
 
-	if t.slice == nil {
-		t.slice = make([]string, t.nColumns)
+	if t.bufRowsDumped {
+		t.sepPending = false // no rows follow
+		t.emit("footer", style, t.writeTail)
+		t.flushWriter()
+		t.flushTeeWriters()
+		return nil
 	}
-	slice := t.slice
-
-	lenPad2 := len(style.Padding) * 2
 
 	// ------------------------------------------------
-	// only need to append the bottown line
+	// dump all buffered line
 
-	if t.bufRowsDumped {
-		// bottom line
-		if style.LineBottom.Visible() {
-			buf.WriteString(style.LineBottom.Begin)
-			for i, M := range t.maxWidths {
-				slice[i] = strings.Repeat(style.LineBottom.Hline, M+lenPad2)
-			}
-			buf.WriteString(strings.Join(slice, style.LineBottom.Sep))
-			buf.WriteString(style.LineBottom.End)
-			buf.WriteString("\n")
+	data, err := t.render(style)
+	if err != nil && t.err == nil {
+		t.err = err
+	}
+	t.write("table", data)
+	for i, tw := range t.teeWriters {
+		data, _ = t.render(tw.styleOr(style))
+		t.writeTee(i, "table", data)
+	}
+	if t.writeErr == nil {
+		t.notifyRows(t.nRowsShown())
+	}
+	t.flushWriter()
+	t.flushTeeWriters()
+	return nil
+}
 
-			t.writer.Write(buf.Bytes())
-			buf.Reset()
+// flushWriter flushes the writer if it is buffered, e.g., a *bufio.Writer.
+func (t *Table) flushWriter() {
+	if w, ok := t.writer.(interface{ Flush() error }); ok && t.writeErr == nil {
+		if err := w.Flush(); err != nil {
+			t.writeErr = fmt.Errorf("stable: flushing the writer: %w", err)
+			if t.err == nil {
+				t.err = t.writeErr
+			}
 		}
-		return
 	}
+}
 
-	// ------------------------------------------------
-	// dump all buffered line
+// ErrCheckpointNotStreaming means Checkpoint() is called before the buffered rows are written,
+// or after calling Flush().
+var ErrCheckpointNotStreaming = fmt.Errorf("stable: calling Checkpoint is only allowed after the buffered rows are written in streaming mode")
+
+// Checkpoint closes the current table block by writing the bottom line,
+// and starts a new one with the top line and the header,
+// using the same column widths, so all blocks are aligned.
+// It is only allowed in streaming mode after the buffered rows are written,
+// and before calling Flush().
+func (t *Table) Checkpoint() error {
+	if t.mu != nil {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+	}
+	if !t.hasWriter || !t.bufRowsDumped || t.flushed {
+		return ErrCheckpointNotStreaming
+	}
+	if err := t.commitPending(); err != nil {
+		return err
+	}
+	if t.writeErr != nil {
+		return t.writeErr
+	}
 
-	t.writer.Write(t.Render(style))
-	buf.Reset()
+	style := t.style
+	if style == nil { // not defined in the object
+		style = StyleGrid
+	}
+
+	t.emit("checkpoint", style, func(buf *bytes.Buffer, style *TableStyle) {
+		if style.LineBottom.Visible() {
+			t.writeLine(buf, style, style.LineBottom)
+		}
+		t.writeHead(buf, style)
+	})
+
+	t.afterSeparator = true // the next row is the first one of the block
+	t.sepPending = false
+	t.prevRow = nil
+	return t.writeErr
+}
+
+// ErrNextTableBeforeFlush means NextTable() is called before calling Flush() in streaming mode.
+var ErrNextTableBeforeFlush = fmt.Errorf("stable: calling NextTable is only allowed after calling Flush() in streaming mode")
+
+// NextTable starts a new table after calling Flush() in streaming mode,
+// which is written to the same writer. The writer, style, header, columns, and other
+// options are retained, while rows are cleared and widths are determined again
+// by the buffered rows of the new table.
+// The error of writing the previous table, if there is, is returned.
+func (t *Table) NextTable() error {
+	if t.mu != nil {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+	}
+	if !t.hasWriter || !t.flushed {
+		return ErrNextTableBeforeFlush
+	}
+	if t.writeErr != nil {
+		return t.writeErr
+	}
+
+	t.rows = nil
+	t.rawRows = nil
+	t.markers = nil
+	t.pending, t.pendingCount, t.pendingRaw = nil, 0, nil
+	t.runCount = 0
+	t.prevRow, t.merged = nil, nil
+	t.rowNumber = 0
+	t.nOmitted = 0
+	t.afterSeparator, t.sepPending = false, false
+	t.resetSummary()
+	t.footer = nil
+
+	t.dataAdded = false
+	t.widthsChecked = false
+	t.bufRowsDumped = false
+	t.nStreamed = 0
+	t.nNotified = 0
+	t.nStable, t.bufMaxWidths = 0, nil
+	t.flushed = false
+	return nil
 }
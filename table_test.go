@@ -20,9 +20,21 @@
 package stable
 
 import (
+	"bufio"
+	"bytes"
+	"errors"
 	"fmt"
+	"io"
+	"math/rand"
 	"os"
+	"reflect"
+	"runtime"
+	"strconv"
+	"strings"
 	"testing"
+	"unicode/utf8"
+
+	"github.com/mattn/go-runewidth"
 )
 
 func TestBasic(t *testing.T) {
@@ -68,7 +80,45 @@ func TestUnicode(t *testing.T) {
 	tbl.AddRow([]interface{}{1000, "沈	伟", "There's one tab between the two words"})
 	tbl.AddRow([]interface{}{100000, "沈伟", "谢谢，我很好，你呢？"})
 
-	fmt.Printf("%s\n", tbl.Render(StyleGrid))
+	out := tbl.Render(StyleGrid)
+	fmt.Printf("%s\n", out)
+
+	// all lines should have the same display width
+	lines := strings.Split(strings.TrimSuffix(string(out), "\n"), "\n")
+	for _, line := range lines {
+		if runewidth.StringWidth(line) != runewidth.StringWidth(lines[0]) {
+			t.Errorf("unmatched line width: %q", line)
+		}
+	}
+
+	// a CJK column is not wider than necessary
+	tbl = New()
+	tbl.Header([]string{"名字", "é"})
+	tbl.AddRow([]interface{}{"沈伟", "café"})
+	expect := `+------+------+
+| 名字 | é    |
++======+======+
+| 沈伟 | café |
++------+------+
+`
+	if out := string(tbl.Render(StyleGrid)); out != expect {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+
+	// wrapping by display width
+	tbl = New().MaxWidth(6)
+	tbl.Header([]string{"id", "text"})
+	tbl.AddRow([]interface{}{1, "谢谢，我很好"})
+	expect = `+----+--------+
+| id | text   |
++====+========+
+| 1  | 谢谢， |
+|    | 我很好 |
++----+--------+
+`
+	if out := string(tbl.Render(StyleGrid)); out != expect {
+		t.Errorf("unexpected output:\n%s", out)
+	}
 }
 
 func TestCustomColumns(t *testing.T) {
@@ -128,3 +178,2724 @@ func TestTaxonomicLineages(t *testing.T) {
 
 	fmt.Printf("%s\n", tbl.WrapDelimiter(';').AlignLeft().MaxWidth(50).Render(StyleGrid))
 }
+
+func TestFilter(t *testing.T) {
+	tbl := New()
+	tbl.Header([]string{"job", "status"})
+	tbl.AddRow([]interface{}{"a", "ok"})
+	tbl.AddRow([]interface{}{"a very long job name", "failed"})
+	tbl.AddRow([]interface{}{"c", "failed"})
+
+	ok := string(tbl.Filter(func(row []string) bool { return row[1] == "ok" }).Render(StylePlain))
+	failed := string(tbl.Filter(func(row []string) bool { return row[1] == "failed" }).Render(StylePlain))
+
+	if ok != "job   status\na     ok    \n" {
+		t.Errorf("unexpected output:\n%s", ok)
+	}
+	if strings.Count(failed, "\n") != 3 || strings.Contains(failed, "ok") {
+		t.Errorf("unexpected output:\n%s", failed)
+	}
+	if len(tbl.rows) != 3 {
+		t.Errorf("rows of the original table should be untouched")
+	}
+}
+
+func TestCaption(t *testing.T) {
+	tbl := New().Caption("* values are medians across 3 runs of the benchmark\nsecond line")
+	tbl.Header([]string{"id", "value"})
+	tbl.AddRow([]interface{}{1, 100})
+	tbl.AddRow([]interface{}{2, 200})
+
+	out := string(tbl.Render(StyleGrid))
+	lines := strings.Split(strings.TrimSuffix(out, "\n"), "\n")
+	width := len(lines[0])
+	if width != 14 {
+		t.Errorf("caption should not affect column widths:\n%s", out)
+	}
+	if lines[7] != "* values are" || lines[len(lines)-1] != "second line" {
+		t.Errorf("unexpected caption:\n%s", out)
+	}
+	for _, line := range lines[7:] {
+		if len(line) > width {
+			t.Errorf("caption line longer than the table: %q", line)
+		}
+	}
+
+	// streaming
+	var buf bytes.Buffer
+	tbl = New().Caption("note")
+	tbl.Writer(&buf, 1)
+	tbl.Style(StyleGrid)
+	tbl.Header([]string{"id", "value"})
+	tbl.AddRow([]interface{}{1, 100})
+	tbl.AddRow([]interface{}{2, 200})
+	tbl.Flush()
+	if !strings.HasSuffix(buf.String(), "+\nnote\n") {
+		t.Errorf("unexpected caption in streaming mode:\n%s", buf.String())
+	}
+}
+
+func TestSection(t *testing.T) {
+	tbl := New()
+	tbl.Header([]string{"chr", "pos", "gene"})
+	tbl.AddSection("chromosome 1")
+	tbl.AddRow([]interface{}{"1", 100, "abc"})
+	tbl.AddRow([]interface{}{"1", 200, "abd"})
+	tbl.AddSection("chromosome 2")
+	tbl.AddRow([]interface{}{"2", 300, "xyz"})
+
+	expect0 := `+-----+-----+------+
+| chr | pos | gene |
++=====+=====+======+
+|   chromosome 1   |
++-----+-----+------+
+| 1   | 100 | abc  |
++-----+-----+------+
+| 1   | 200 | abd  |
++-----+-----+------+
+|   chromosome 2   |
++-----+-----+------+
+| 2   | 300 | xyz  |
++-----+-----+------+
+`
+	if out := string(tbl.Render(StyleGrid)); out != expect0 {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+
+	expect := `chr   pos   gene
+  chromosome 1  
+1     100   abc 
+1     200   abd 
+  chromosome 2  
+2     300   xyz 
+`
+	if out := string(tbl.Render(StylePlain)); out != expect {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+
+	// a long label is wrapped
+	tbl.AddSection("a very long section label exceeding the width")
+	out := string(tbl.Render(StyleGrid))
+	lines := strings.Split(strings.TrimSuffix(out, "\n"), "\n")
+	for _, line := range lines {
+		if len(line) != len(lines[0]) {
+			t.Errorf("unmatched line width: %q", line)
+		}
+	}
+
+	// streaming
+	var buf bytes.Buffer
+	tbl = New()
+	tbl.Writer(&buf, 1)
+	tbl.Style(StyleGrid)
+	tbl.Header([]string{"chr", "pos", "gene"})
+	tbl.AddSection("chromosome 1")
+	tbl.AddRow([]interface{}{"1", 100, "abc"})
+	tbl.AddRow([]interface{}{"1", 200, "abd"})
+	tbl.AddSection("chromosome 2")
+	tbl.AddRow([]interface{}{"2", 300, "xyz"})
+	tbl.Flush()
+	if buf.String() != expect0 {
+		t.Errorf("unexpected output in streaming mode:\n%s", buf.String())
+	}
+	// errors of writing are reported as the ones of sections
+	w := &limitedWriter{n: 1 << 20}
+	tbl = New()
+	tbl.Writer(w, 1)
+	tbl.Header([]string{"chr", "pos"})
+	tbl.AddRow([]interface{}{"1", 100})
+	tbl.AddRow([]interface{}{"1", 200})
+	w.n = w.buffer.Len()
+	if err := tbl.AddSection("chromosome 2"); err == nil || err.Error() != "stable: writing section: broken pipe" {
+		t.Errorf("expected an error of writing the section, got %v", err)
+	}
+}
+
+func TestSectionLabelFitting(t *testing.T) {
+	// a wide character in a narrow table
+	for _, style := range []*TableStyle{StylePlain, StyleGrid} {
+		tbl := New()
+		tbl.AddRow([]interface{}{"a"})
+		tbl.AddSection("谢谢")
+		out := string(tbl.Render(style))
+		lines := strings.Split(strings.TrimSuffix(out, "\n"), "\n")
+		for _, line := range lines {
+			if runewidth.StringWidth(line) != runewidth.StringWidth(lines[0]) {
+				t.Errorf("%s: misaligned line: %q", style.Name, line)
+			}
+		}
+	}
+
+	// multi-line labels
+	tbl := New().Convert(map[string]string{})
+	tbl.Header([]string{"id", "name"})
+	tbl.AddSection("x\ny")
+	tbl.AddRow([]interface{}{1, "a"})
+	expect := `+----+------+
+| id | name |
++====+======+
+|     x     |
+|     y     |
++----+------+
+| 1  | a    |
++----+------+
+`
+	if out := string(tbl.Render(StyleGrid)); out != expect {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+
+	// newlines are replaced in single-line mode
+	tbl.SingleLine()
+	if out := string(tbl.Render(StyleGrid)); !strings.Contains(out, "|    x↵y    |\n") {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+}
+
+func TestSeparator(t *testing.T) {
+	tbl := New()
+	tbl.Header([]string{"chr", "pos"})
+	tbl.AddSeparator() // ignored
+	tbl.AddRow([]interface{}{"1", 100})
+	tbl.AddRow([]interface{}{"1", 200})
+	tbl.AddSeparator()
+	tbl.AddSeparator() // collapsed
+	tbl.AddRow([]interface{}{"2", 300})
+
+	expect0 := `+-----+-----+
+| chr | pos |
++=====+=====+
+| 1   | 100 |
++-----+-----+
+| 1   | 200 |
++-----+-----+
+| 2   | 300 |
++-----+-----+
+`
+	if out := string(tbl.Render(StyleGrid)); out != expect0 {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+
+	expect := `chr   pos
+1     100
+1     200
+---   ---
+2     300
+`
+	if out := string(tbl.Render(StylePlain)); out != expect {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+
+	tbl.EmphasizeSeparators()
+	expect = `+-----+-----+
+| chr | pos |
++=====+=====+
+| 1   | 100 |
++-----+-----+
+| 1   | 200 |
++=====+=====+
+| 2   | 300 |
++-----+-----+
+`
+	if out := string(tbl.Render(StyleGrid)); out != expect {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+
+	// streaming
+	var buf bytes.Buffer
+	tbl = New()
+	tbl.Writer(&buf, 1)
+	tbl.Style(StyleGrid)
+	tbl.Header([]string{"chr", "pos"})
+	tbl.AddRow([]interface{}{"1", 100})
+	tbl.AddRow([]interface{}{"1", 200})
+	tbl.AddSeparator()
+	tbl.AddSeparator()
+	tbl.AddRow([]interface{}{"2", 300})
+	tbl.AddSeparator() // ignored, as no rows follow
+	tbl.Flush()
+	if buf.String() != expect0 {
+		t.Errorf("unexpected output in streaming mode:\n%s", buf.String())
+	}
+
+	// a separator after the last row
+	tbl = New()
+	tbl.Header([]string{"chr", "pos"})
+	tbl.AddRow([]interface{}{"1", 100})
+	tbl.AddRow([]interface{}{"1", 200})
+	tbl.AddSeparator()
+	tbl.AddRow([]interface{}{"2", 300})
+	tbl.AddSeparator()
+	if out := string(tbl.Render(StyleGrid)); out != expect0 {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+}
+
+func TestMergeCells(t *testing.T) {
+	tbl := New()
+	tbl.HeaderWithFormat([]Column{
+		{Header: "group", MergeCells: true},
+		{Header: "item"},
+	})
+	tbl.AddRow([]interface{}{"a", 1})
+	tbl.AddRow([]interface{}{"a", 2})
+	tbl.AddSection("more")
+	tbl.AddRow([]interface{}{"a", 3})
+	tbl.AddRow([]interface{}{"b", 4})
+
+	expect := `+-------+------+
+| group | item |
++=======+======+
+| a     | 1    |
+|       +------+
+|       | 2    |
++-------+------+
+|     more     |
++-------+------+
+| a     | 3    |
++-------+------+
+| b     | 4    |
++-------+------+
+`
+	if out := string(tbl.Render(StyleGrid)); out != expect {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+
+	expect = `group   item
+a       1   
+        2   
+    more    
+a       3   
+b       4   
+`
+	if out := string(tbl.Render(StylePlain)); out != expect {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+}
+
+func TestRowNumbers(t *testing.T) {
+	for _, n := range []int{9, 10, 99, 100} {
+		tbl := New().ShowRowNumbers("#")
+		tbl.Header([]string{"name"})
+		for i := 0; i < n; i++ {
+			tbl.AddRow([]interface{}{"x"})
+		}
+		lines := strings.Split(strings.TrimSuffix(string(tbl.Render(StylePlain)), "\n"), "\n")
+		digits := len(strconv.Itoa(n))
+		if expect := strings.Repeat(" ", digits-1) + "#   name"; lines[0] != expect {
+			t.Errorf("n=%d: unexpected header: %q", n, lines[0])
+		}
+		if expect := strings.Repeat(" ", digits-1) + "1   x   "; lines[1] != expect {
+			t.Errorf("n=%d: unexpected first row: %q", n, lines[1])
+		}
+		if expect := strconv.Itoa(n) + "   x   "; lines[n] != expect {
+			t.Errorf("n=%d: unexpected last row: %q", n, lines[n])
+		}
+	}
+
+	// wrapped rows
+	tbl := New().ShowRowNumbers("#").MaxWidth(10)
+	tbl.Header([]string{"text"})
+	tbl.AddRow([]interface{}{"a long text to wrap"})
+	expect := `+===+============+
+| 1 | a long     |
+|   | text to    |
+|   | wrap       |
++---+------------+
+`
+	if out := string(tbl.Render(StyleGrid)); !strings.HasSuffix(out, expect) {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+
+	// streaming, the width is reserved
+	var buf bytes.Buffer
+	tbl = New().ShowRowNumbers("").Style(StyleGrid)
+	tbl.Writer(&buf, 2)
+	for i := 0; i < 10; i++ {
+		tbl.AddRow([]interface{}{"x"})
+	}
+	tbl.Flush()
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if lines[1] != "|      1 | x |" || lines[len(lines)-2] != "|     10 | x |" {
+		t.Errorf("unexpected output in streaming mode:\n%s", buf.String())
+	}
+	for _, line := range lines {
+		if len(line) != len(lines[0]) {
+			t.Errorf("misaligned line: %q", line)
+		}
+	}
+
+	// row numbers outgrowing the reserved width are clipped, not wrapped
+	buf.Reset()
+	tbl = New().ShowRowNumbers("")
+	tbl.Writer(&buf, 2)
+	for i := 0; i < 3; i++ {
+		tbl.AddRow([]interface{}{"x"})
+	}
+	buf.Reset()
+	tbl.rowNumber = 999998 // skip writing so many rows
+	for i := 0; i < 2; i++ {
+		tbl.AddRow([]interface{}{"x"})
+	}
+	tbl.Flush()
+	lines = strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 2 || lines[0] != "999999   x" || lines[1] != "…00000   x" {
+		t.Errorf("unexpected row numbers in streaming mode:\n%s", buf.String())
+	}
+
+	// a wide header of the row-number column
+	tbl = New().ShowRowNumbers("序号")
+	tbl.Header([]string{"name"})
+	for i := 0; i < 10000; i++ {
+		tbl.AddRow([]interface{}{"x"})
+	}
+	lines = strings.Split(strings.TrimSuffix(string(tbl.Render(StyleGrid)), "\n"), "\n")
+	for _, line := range lines {
+		if runewidth.StringWidth(line) != runewidth.StringWidth(lines[0]) {
+			t.Errorf("misaligned line: %q", line)
+		}
+	}
+}
+
+func TestZebra(t *testing.T) {
+	decorate := func(line string) string { return "\x1b[47m" + line + "\x1b[0m" }
+
+	tbl := New().MaxWidth(10).Zebra(decorate)
+	tbl.Header([]string{"id", "text"})
+	tbl.AddRow([]interface{}{1, "short"})
+	tbl.AddRow([]interface{}{2, "a long text to wrap"})
+	tbl.AddRow([]interface{}{3, "short"})
+	tbl.AddRow([]interface{}{4, "short"})
+
+	out := string(tbl.Render(StyleGrid))
+	if n := strings.Count(out, "\x1b[47m"); n != 4 { // 3 lines of row 2 and 1 line of row 4
+		t.Errorf("expected 4 decorated lines, got %d:\n%s", n, out)
+	}
+	for _, line := range strings.Split(out, "\n") {
+		if strings.HasPrefix(line, "+") && strings.Contains(line, "\x1b") {
+			t.Errorf("border line should not be decorated: %q", line)
+		}
+	}
+
+	// streaming
+	var buf bytes.Buffer
+	tbl = New().MaxWidth(10).Zebra(decorate)
+	tbl.Writer(&buf, 1)
+	tbl.Header([]string{"id", "text"})
+	tbl.AddRow([]interface{}{1, "short"})
+	tbl.AddRow([]interface{}{2, "a long text to wrap"})
+	tbl.AddRow([]interface{}{3, "short"})
+	tbl.AddRow([]interface{}{4, "short"})
+	tbl.Flush()
+	if n := strings.Count(buf.String(), "\x1b[47m"); n != 6 { // widths are determined by the first row
+		t.Errorf("expected 6 decorated lines in streaming mode, got %d:\n%s", n, buf.String())
+	}
+}
+
+func TestRepeatHeader(t *testing.T) {
+	tbl := New().RepeatHeaderEvery(2)
+	tbl.Header([]string{"id"})
+	for i := 1; i <= 5; i++ {
+		tbl.AddRow([]interface{}{i})
+	}
+	expect := `+----+
+| id |
++====+
+| 1  |
++----+
+| 2  |
++----+
+| id |
++====+
+| 3  |
++----+
+| 4  |
++----+
+| id |
++====+
+| 5  |
++----+
+`
+	if out := string(tbl.Render(StyleGrid)); out != expect {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+
+	// the last repetition is not followed by the bottom line
+	tbl.rows = tbl.rows[:4]
+	if out := string(tbl.Render(StylePlain)); out != "id\n1 \n2 \nid\n3 \n4 \n" {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+
+	// streaming, across the bufRows boundary
+	var buf bytes.Buffer
+	tbl = New().RepeatHeaderEvery(2)
+	tbl.Writer(&buf, 3)
+	tbl.Style(StyleGrid)
+	tbl.Header([]string{"id"})
+	for i := 1; i <= 5; i++ {
+		tbl.AddRow([]interface{}{i})
+	}
+	tbl.Flush()
+	if buf.String() != expect {
+		t.Errorf("unexpected output in streaming mode:\n%s", buf.String())
+	}
+}
+
+func TestFlexibleColumns(t *testing.T) {
+	tbl := New().FlexibleColumns()
+	tbl.Header([]string{"a"})
+	tbl.AddRow([]interface{}{1})
+	tbl.AddRow([]interface{}{1, 2})
+	tbl.AddRow([]interface{}{1, 2, 3})
+	tbl.AddRow([]interface{}{1, 2})
+	tbl.AddRow([]interface{}{1})
+
+	expect := `+---+---+---+
+| a |   |   |
++===+===+===+
+| 1 |   |   |
++---+---+---+
+| 1 | 2 |   |
++---+---+---+
+| 1 | 2 | 3 |
++---+---+---+
+| 1 | 2 |   |
++---+---+---+
+| 1 |   |   |
++---+---+---+
+`
+	if out := string(tbl.Render(StyleGrid)); out != expect {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+
+	if err := New().AddRow([]interface{}{1}); err != nil {
+		t.Error(err)
+	}
+
+	// streaming
+	var buf bytes.Buffer
+	tbl = New().FlexibleColumns()
+	tbl.Writer(&buf, 1)
+	tbl.AddRow([]interface{}{1})
+	tbl.AddRow([]interface{}{1, 2})
+	if err := tbl.AddRow([]interface{}{1, 2, 3}); err != ErrGrowColumnsAfterRowsWritten {
+		t.Errorf("expected ErrGrowColumnsAfterRowsWritten, got %v", err)
+	}
+	if err := tbl.AddRow([]interface{}{1}); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestRenderPreview(t *testing.T) {
+	tbl := New().ShowRowNumbers("#")
+	tbl.Header([]string{"id", "text"})
+	for i := 1; i <= 2000; i++ {
+		text := "x"
+		if i == 1000 {
+			text = "a long text which is omitted"
+		}
+		tbl.AddRow([]interface{}{i, text})
+	}
+
+	expect := `+------+------+------+
+|    # | id   | text |
++======+======+======+
+|    1 | 1    | x    |
++------+------+------+
+|    2 | 2    | x    |
++------+------+------+
+|    … 1,997 rows    |
+|     omitted …      |
++------+------+------+
+| 2000 | 2000 | x    |
++------+------+------+
+`
+	if out := string(tbl.RenderPreview(StyleGrid, 2, 1)); out != expect {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+
+	// compare with manual slice-and-render, without row numbers
+	tbl.rowNumbers = false
+	out := string(tbl.RenderPreview(StylePlain, 2, 1))
+	tbl2 := New()
+	tbl2.Header([]string{"id", "text"})
+	tbl2.AddRow([]interface{}{1, "x"})
+	tbl2.AddRow([]interface{}{2, "x"})
+	tbl2.AddSection("… 1,997 rows omitted …")
+	tbl2.AddRow([]interface{}{2000, "x"})
+	if expect := string(tbl2.Render(StylePlain)); out != expect {
+		t.Errorf("unexpected output:\n%s\nexpected:\n%s", out, expect)
+	}
+
+	// all rows
+	if string(tbl.RenderPreview(StylePlain, 1000, 1000)) != string(tbl.Render(StylePlain)) {
+		t.Errorf("all rows should be rendered")
+	}
+}
+
+func TestEditRows(t *testing.T) {
+	tbl := New()
+	tbl.Header([]string{"id", "text"})
+	tbl.AddRow([]interface{}{1, "short"})
+	tbl.AddRow([]interface{}{2, "the longest text"})
+	tbl.AddRow([]interface{}{3, "text"})
+
+	width := func() int {
+		tbl.checkWidths()
+		return tbl.maxWidths[1]
+	}
+	if w := width(); w != 16 {
+		t.Errorf("unexpected width: %d", w)
+	}
+
+	if err := tbl.UpdateCell(1, 1, "shorter"); err != nil {
+		t.Fatal(err)
+	}
+	if w := width(); w != 7 {
+		t.Errorf("width should shrink to 7, got %d", w)
+	}
+
+	if err := tbl.UpdateRow(0, []interface{}{1, "a bit longer"}); err != nil {
+		t.Fatal(err)
+	}
+	if w := width(); w != 12 {
+		t.Errorf("width should grow to 12, got %d", w)
+	}
+
+	if err := tbl.RemoveRow(0); err != nil {
+		t.Fatal(err)
+	}
+	if w := width(); w != 7 || len(tbl.rows) != 2 || tbl.rows[0][0] != "2" {
+		t.Errorf("unexpected rows after removing: %v", tbl.rows)
+	}
+
+	if err := tbl.RemoveRow(2); !errors.Is(err, ErrInvalidRowIndex) {
+		t.Errorf("expected ErrInvalidRowIndex, got %v", err)
+	}
+	if err := tbl.UpdateCell(0, 2, 1); !errors.Is(err, ErrInvalidColumnIndex) {
+		t.Errorf("expected ErrInvalidColumnIndex, got %v", err)
+	}
+	if err := tbl.UpdateRow(0, []interface{}{1}); !errors.Is(err, ErrUnmatchedColumnNumber) {
+		t.Errorf("expected ErrUnmatchedColumnNumber, got %v", err)
+	}
+
+	// streaming
+	var buf bytes.Buffer
+	tbl = New()
+	tbl.Writer(&buf, 1)
+	tbl.AddRow([]interface{}{1})
+	if err := tbl.UpdateCell(0, 0, 2); err != nil {
+		t.Errorf("updating buffered rows should be allowed: %v", err)
+	}
+	tbl.AddRow([]interface{}{3})
+	if err := tbl.RemoveRow(0); err != ErrEditAfterRowsWritten {
+		t.Errorf("expected ErrEditAfterRowsWritten, got %v", err)
+	}
+}
+
+func TestNumRowsAndColumns(t *testing.T) {
+	tbl := New()
+	if tbl.NumRows() != 0 || tbl.NumColumns() != 0 || tbl.HasHeader() {
+		t.Errorf("unexpected counts of an empty table")
+	}
+	tbl.Header([]string{"a", "b"})
+	if tbl.NumRows() != 0 || tbl.NumColumns() != 2 || !tbl.HasHeader() {
+		t.Errorf("unexpected counts after setting the header")
+	}
+	tbl.AddRow([]interface{}{1, 2})
+	tbl.AddRow([]interface{}{3, 4})
+	if tbl.NumRows() != 2 {
+		t.Errorf("expected 2 rows, got %d", tbl.NumRows())
+	}
+
+	var buf bytes.Buffer
+	tbl = New()
+	tbl.Writer(&buf, 2)
+	for i := 0; i < 5; i++ {
+		tbl.AddRow([]interface{}{i, i, i})
+		if tbl.NumRows() != i+1 {
+			t.Errorf("expected %d rows in streaming mode, got %d", i+1, tbl.NumRows())
+		}
+	}
+	if tbl.NumColumns() != 3 || tbl.HasHeader() {
+		t.Errorf("unexpected columns in streaming mode")
+	}
+}
+
+func TestRowsAndHeaders(t *testing.T) {
+	tbl := New().HumanizeNumbers()
+	if tbl.Headers() != nil {
+		t.Errorf("headers should be nil")
+	}
+	tbl.Header([]string{"a", "b"})
+	tbl.AddRow([]interface{}{1000, "x"})
+
+	rows := tbl.Rows()
+	if !reflect.DeepEqual(rows, [][]string{{"1,000", "x"}}) {
+		t.Errorf("unexpected rows: %v", rows)
+	}
+	rows[0][0] = "changed"
+	if tbl.rows[0][0] != "1,000" {
+		t.Errorf("rows should be copied")
+	}
+
+	headers := tbl.Headers()
+	if !reflect.DeepEqual(headers, []string{"a", "b"}) {
+		t.Errorf("unexpected headers: %v", headers)
+	}
+	headers[0] = "changed"
+	if tbl.columns[0].Header != "a" {
+		t.Errorf("headers should be copied")
+	}
+
+	// streaming
+	var buf bytes.Buffer
+	tbl = New()
+	tbl.Writer(&buf, 2)
+	tbl.AddRow([]interface{}{1})
+	if len(tbl.Rows()) != 1 {
+		t.Errorf("buffered rows should be returned")
+	}
+}
+
+func TestVAlign(t *testing.T) {
+	text := "one two six ten end"
+
+	tests := []struct {
+		valign VAlign
+		expect string
+	}{
+		{VAlignTop, `| a | one   | b |
+|   | two   |   |
+|   | six   |   |
+|   | ten   |   |
+|   | end   |   |
+`},
+		{VAlignMiddle, `|   | one   |   |
+|   | two   |   |
+| a | six   | b |
+|   | ten   |   |
+|   | end   |   |
+`},
+		{VAlignBottom, `|   | one   |   |
+|   | two   |   |
+|   | six   |   |
+|   | ten   |   |
+| a | end   | b |
+`},
+	}
+	for _, test := range tests {
+		tbl := New().MaxWidth(5)
+		tbl.VAlign(test.valign)
+		tbl.Header([]string{"x", "y", "z"})
+		tbl.AddRow([]interface{}{"a", text, "b"})
+		out := string(tbl.Render(StyleGrid))
+		if !strings.Contains(out, test.expect) {
+			t.Errorf("%s: unexpected output:\n%s", test.valign, out)
+		}
+	}
+
+	// column-level option overrides the global one
+	tbl := New().MaxWidth(5)
+	tbl.VAlign(VAlignBottom)
+	tbl.HeaderWithFormat([]Column{{Header: "x", VAlign: VAlignTop}, {Header: "y"}, {Header: "z"}})
+	tbl.AddRow([]interface{}{"a", text, "b"})
+	out := string(tbl.Render(StyleGrid))
+	if !strings.Contains(out, "| a | one   |   |\n") || !strings.Contains(out, "|   | end   | b |\n") {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+
+	if _, err := tbl.VAlign(0); err != ErrInvalidVAlign {
+		t.Errorf("expected ErrInvalidVAlign, got %v", err)
+	}
+}
+
+var allStyles = []*TableStyle{
+	StylePlain,
+	StyleSimple,
+	StyleThreeLine,
+	StyleGrid,
+	StyleLight,
+	StyleRound,
+	StyleBold,
+	StyleDouble,
+}
+
+func TestEmptyTable(t *testing.T) {
+	for _, style := range allStyles {
+		// header only
+		tbl := New()
+		tbl.Header([]string{"id", "name"})
+		out := string(tbl.Render(style))
+		lines := strings.Split(strings.TrimSuffix(out, "\n"), "\n")
+		var n int
+		for _, line := range lines {
+			if strings.Contains(line, "id") {
+				n++
+			}
+			if runewidth.StringWidth(line) != runewidth.StringWidth(lines[0]) {
+				t.Errorf("%s: unmatched line width:\n%s", style.Name, out)
+				break
+			}
+		}
+		if n != 1 {
+			t.Errorf("%s: the header should be rendered once:\n%s", style.Name, out)
+		}
+
+		// empty headers and no rows
+		tbl = New()
+		tbl.Header([]string{"", ""})
+		tbl.Render(style)
+
+		// nothing
+		if out := New().Render(style); len(out) != 0 {
+			t.Errorf("%s: an empty table should render nothing, got:\n%s", style.Name, out)
+		}
+	}
+}
+
+func TestTranspose(t *testing.T) {
+	tbl := New()
+	tbl.Header([]string{"name", "a", "b", "c"})
+	tbl.AddRow([]interface{}{"x", 1, 2, 3})
+	tbl.AddRow([]interface{}{"y", 4, 5, 6})
+
+	t2, err := tbl.Transpose()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := [][]string{
+		{"name", "x", "y"},
+		{"a", "1", "4"},
+		{"b", "2", "5"},
+		{"c", "3", "6"},
+	}
+	if !reflect.DeepEqual(t2.Rows(), expect) || t2.HasHeader() {
+		t.Errorf("unexpected transposed table:\n%s", t2.Render(StyleGrid))
+	}
+	for i, row := range tbl.Rows() {
+		for j, cell := range row {
+			if t2.Rows()[j][i+1] != cell {
+				t.Errorf("cell (%d, %d) not found in the transposed table", i, j)
+			}
+		}
+	}
+
+	t2, err = tbl.TransposeBy("name")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(t2.Headers(), []string{"name", "x", "y"}) || !reflect.DeepEqual(t2.Rows(), expect[1:]) {
+		t.Errorf("unexpected transposed table:\n%s", t2.Render(StyleGrid))
+	}
+
+	var buf bytes.Buffer
+	tbl = New()
+	tbl.Writer(&buf, 1)
+	if _, err = tbl.Transpose(); err != ErrTransposeInStreamingMode {
+		t.Errorf("expected ErrTransposeInStreamingMode, got %v", err)
+	}
+}
+
+func TestAddRowsFromSlices(t *testing.T) {
+	tbl1 := New()
+	tbl1.HeaderValues("id", "name")
+	tbl1.AddRowValues(1, "a&b")
+	tbl1.AddRowValues("2", "c")
+
+	tbl2 := New()
+	tbl2.Header([]string{"id", "name"})
+	rows := [][]string{{"1", "a&b"}, {"2", "c"}}
+	if err := tbl2.AddRowsFromSlices(rows); err != nil {
+		t.Fatal(err)
+	}
+	rows[0][1] = "changed" // rows are copied
+	if !reflect.DeepEqual(tbl1.Rows(), tbl2.Rows()) {
+		t.Errorf("unmatched rows: %v, %v", tbl1.Rows(), tbl2.Rows())
+	}
+
+	if err := tbl2.AddRowsFromSlices([][]string{{"3", "d"}, {"4"}}); !errors.Is(err, ErrUnmatchedColumnNumber) {
+		t.Errorf("expected ErrUnmatchedColumnNumber, got %v", err)
+	}
+	// column conversion
+	tbl1 = New().HumanizeNumbers()
+	tbl1.HeaderWithFormat([]Column{
+		{Header: "id"},
+		{Header: "bytes", ConvertFunc: func(v interface{}) (interface{}, error) {
+			return strconv.Atoi(v.(string))
+		}},
+	})
+	if err := tbl1.AddRowsFromSlices([][]string{{"1", "1048576"}}); err != nil {
+		t.Fatal(err)
+	}
+	if expect := [][]string{{"1", "1,048,576"}}; !reflect.DeepEqual(tbl1.Rows(), expect) {
+		t.Errorf("unexpected rows: %v", tbl1.Rows())
+	}
+}
+
+func TestUnmatchedColumnNumber(t *testing.T) {
+	// with a header
+	tbl := New()
+	tbl.Header([]string{"a", "b", "c"})
+	err := tbl.AddRow([]interface{}{1, "x", 2.5, true})
+	if !errors.Is(err, ErrUnmatchedColumnNumber) {
+		t.Errorf("expected ErrUnmatchedColumnNumber, got %v", err)
+	}
+	if msg := err.Error(); msg != `stable: unmatched column number: expected 3, got 4, in the row of [1, "x", 2.5, ...]` {
+		t.Errorf("unexpected message: %s", msg)
+	}
+
+	// without a header, the first row determines the number of columns
+	tbl = New()
+	tbl.AddRowStringSlice([]string{"a", "b"})
+	err = tbl.AddRowsFromSlices([][]string{{"c", "d"}, {"e"}})
+	if !errors.Is(err, ErrUnmatchedColumnNumber) {
+		t.Errorf("expected ErrUnmatchedColumnNumber, got %v", err)
+	}
+	if msg := err.Error(); msg != `row 2: stable: unmatched column number: expected 2, got 1, in the row of ["e"]` {
+		t.Errorf("unexpected message: %s", msg)
+	}
+}
+
+func benchmarkRows(n int) [][]string {
+	rows := make([][]string, n)
+	for i := range rows {
+		rows[i] = []string{strconv.Itoa(i), "sample", "ACGTACGTACGT", "0.95"}
+	}
+	return rows
+}
+
+func BenchmarkAddRowStringSlice(b *testing.B) {
+	rows := benchmarkRows(1000)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		tbl := New()
+		for _, row := range rows {
+			tbl.AddRowStringSlice(row)
+		}
+	}
+}
+
+func BenchmarkAddRowsFromSlices(b *testing.B) {
+	rows := benchmarkRows(1000)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		tbl := New()
+		tbl.AddRowsFromSlices(rows)
+	}
+}
+
+func TestMaxRows(t *testing.T) {
+	newTable := func(n int) *Table {
+		tbl := New().MaxRows(3)
+		tbl.Header([]string{"id", "name"})
+		for i := 1; i <= n; i++ {
+			tbl.AddRow([]interface{}{i, "description"})
+		}
+		return tbl
+	}
+
+	// n == NumRows
+	expect := `+----+-------------+
+| id | name        |
++====+=============+
+| 1  | description |
++----+-------------+
+| 2  | description |
++----+-------------+
+| 3  | description |
++----+-------------+
+`
+	if out := string(newTable(3).Render(StyleGrid)); out != expect {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+
+	// n+1
+	expect = `+----+-------------+
+| id | name        |
++====+=============+
+| 1  | description |
++----+-------------+
+| 2  | description |
++----+-------------+
+| 3  | description |
++----+-------------+
+| … and 1 more row |
++----+-------------+
+`
+	if out := string(newTable(4).Render(StyleGrid)); out != expect {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+
+	out := string(newTable(1237).Render(StylePlain))
+	if !strings.HasSuffix(strings.Join(strings.Fields(out), " "), "… and 1,234 more rows") {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+
+	// streaming
+	var buf bytes.Buffer
+	tbl := New().MaxRows(3)
+	tbl.Writer(&buf, 2)
+	tbl.Style(StyleGrid)
+	tbl.Header([]string{"id", "name"})
+	for i := 1; i <= 4; i++ {
+		tbl.AddRow([]interface{}{i, "description"})
+	}
+	tbl.Flush()
+	if buf.String() != expect {
+		t.Errorf("unexpected output in streaming mode:\n%s", buf.String())
+	}
+}
+
+func TestRenderRows(t *testing.T) {
+	tbl := New().ShowRowNumbers("#").SeamlessPages()
+	tbl.Header([]string{"id", "name"})
+	for i := 1; i <= 10; i++ {
+		if i == 5 {
+			tbl.AddSection("second half")
+		}
+		tbl.AddRow([]interface{}{i, strings.Repeat("a", i)})
+	}
+	tbl.Caption("ten rows")
+
+	for _, style := range []*TableStyle{StyleGrid, StyleSimple, StylePlain} {
+		full := string(tbl.Render(style))
+		var pages []string
+		for _, r := range [][2]int{{0, 4}, {4, 7}, {7, 100}} {
+			page, err := tbl.RenderRows(style, r[0], r[1])
+			if err != nil {
+				t.Fatal(err)
+			}
+			pages = append(pages, string(page))
+		}
+		if got := strings.Join(pages, ""); got != full {
+			t.Errorf("concatenated pages differ from the full table:\n%s\n%s", got, full)
+		}
+	}
+
+	// a standalone page
+	tbl = New().PageWidths()
+	tbl.Header([]string{"id", "name"})
+	tbl.AddRow([]interface{}{1, "a"})
+	tbl.AddRow([]interface{}{2, "abcdef"})
+	expect := `+----+------+
+| id | name |
++====+======+
+| 1  | a    |
++----+------+
+`
+	if page, _ := tbl.RenderRows(StyleGrid, -1, 1); string(page) != expect {
+		t.Errorf("unexpected output:\n%s", page)
+	}
+
+	if _, err := tbl.RenderRows(StyleGrid, 2, 1); !errors.Is(err, ErrInvalidRowRange) {
+		t.Errorf("expected ErrInvalidRowRange, got %v", err)
+	}
+}
+
+func TestWrapText(t *testing.T) {
+	text := "谢谢，我很好"
+	for _, w := range []int{4, 5, 20} {
+		lines, _ := wrapText(nil, text, w, " ", 0)
+		if strings.Join(lines, "") != text {
+			t.Errorf("width %d: text changed after wrapping: %q", w, lines)
+		}
+		for _, line := range lines {
+			if runewidth.StringWidth(line) > w {
+				t.Errorf("width %d: line too wide: %q", w, line)
+			}
+		}
+	}
+	if lines, _ := wrapText(nil, text, 4, " ", 0); !reflect.DeepEqual(lines, []string{"谢谢", "，我", "很好"}) {
+		t.Errorf("unexpected lines: %q", lines)
+	}
+
+	// the delimiter is preferred
+	if lines, _ := wrapText(nil, "ab 沈伟", 6, " ", 0); !reflect.DeepEqual(lines, []string{"ab ", "沈伟"}) {
+		t.Errorf("unexpected lines: %q", lines)
+	}
+
+	// a wide character is kept in a narrow column
+	if lines, _ := wrapText(nil, "沈伟", 1, " ", 0); !reflect.DeepEqual(lines, []string{"沈", "伟"}) {
+		t.Errorf("unexpected lines: %q", lines)
+	}
+}
+
+func TestClipMark(t *testing.T) {
+	for _, mark := range []string{"…", "..."} {
+		tbl := New().ClipCell(mark)
+		tbl.HeaderWithFormat([]Column{
+			{Header: "a", MaxWidth: 2},
+			{Header: "b", MaxWidth: 5},
+		})
+		tbl.AddRow([]interface{}{"abcdefgh", "abcdefgh"})
+
+		for k := 0; k < 2; k++ { // the mark is not lost after rendering
+			out := string(tbl.Render(StylePlain))
+			lines := strings.Split(strings.TrimSuffix(out, "\n"), "\n")
+			var expect string
+			if mark == "…" {
+				expect = "a…   abcd…"
+			} else {
+				expect = "ab   ab..."
+			}
+			if lines[1] != expect {
+				t.Errorf("mark %q, render %d: unexpected row: %q", mark, k+1, lines[1])
+			}
+		}
+	}
+}
+
+func TestNarrowColumn(t *testing.T) {
+	tbl := New()
+	tbl.Header([]string{"+", "id"})
+	tbl.AddRow([]interface{}{"-", 1})
+	if out := string(tbl.Render(StyleGrid)); !strings.HasPrefix(out, "+---+----+\n") {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+
+	tbl.MinWidth(5)
+	if out := string(tbl.Render(StyleGrid)); !strings.HasPrefix(out, "+-------+-------+\n") {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+}
+
+func TestWrapDelimiters(t *testing.T) {
+	tbl := New().WrapDelimiters(';', ' ').MaxWidth(20)
+	tbl.Header([]string{"lineage"})
+	tbl.AddRow([]interface{}{"Bacteria;Proteobacteria;Escherichia coli;E. coli K-12"})
+	expect := `lineage             
+Bacteria;           
+Proteobacteria;     
+Escherichia coli;E. 
+coli K-12           
+`
+	if out := string(tbl.Render(StylePlain)); out != expect {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+
+	// the last delimiter before the limit wins
+	if lines, _ := wrapText(nil, "a b;c d;efg", 6, "; ", 0); !reflect.DeepEqual(lines, []string{"a b;c ", "d;efg"}) {
+		t.Errorf("unexpected lines: %q", lines)
+	}
+}
+
+func TestBreakLongWordsWithHyphen(t *testing.T) {
+	token := strings.Repeat("ACGTACGTAC", 6)
+	tbl := New().BreakLongWordsWithHyphen('-').MaxWidth(10)
+	tbl.Header([]string{"id", "seq"})
+	tbl.AddRow([]interface{}{1, token})
+	lines := strings.Split(strings.TrimSuffix(string(tbl.Render(StylePlain)), "\n"), "\n")[1:]
+	if len(lines) != 7 { // 9 characters and a hyphen per line
+		t.Errorf("unexpected number of lines: %d", len(lines))
+	}
+	var seq string
+	for i, line := range lines {
+		cell := strings.TrimSpace(line[5:])
+		if runewidth.StringWidth(cell) > 10 {
+			t.Errorf("line too wide: %q", line)
+		}
+		if i < len(lines)-1 {
+			if !strings.HasSuffix(cell, "-") {
+				t.Errorf("no hyphen at a forced break: %q", line)
+			}
+			cell = strings.TrimSuffix(cell, "-")
+		}
+		seq += cell
+	}
+	if seq != token {
+		t.Errorf("text changed after wrapping: %s", seq)
+	}
+
+	// breaks at delimiters are hyphen-free
+	if lines, _ := wrapText(nil, "abcde fghij abcdefghijkl", 6, " ", '-'); !reflect.DeepEqual(lines, []string{"abcde ", "fghij ", "abcde-", "fghij-", "kl"}) {
+		t.Errorf("unexpected lines: %q", lines)
+	}
+}
+
+func TestMultiLineCells(t *testing.T) {
+	tbl := New().Convert(nil).MaxWidth(10)
+	tbl.Header([]string{"id", "text"})
+	tbl.AddRow([]interface{}{1, "short\nvery very long second line"})
+	expect := `+----+------------+
+| id | text       |
++====+============+
+| 1  | short      |
+|    | very very  |
+|    | long       |
+|    | second     |
+|    | line       |
++----+------------+
+`
+	if out := string(tbl.Render(StyleGrid)); out != expect {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+
+	// each line is clipped
+	tbl.ClipCell("...")
+	expect = `+----+------------+
+| id | text       |
++====+============+
+| 1  | short      |
+|    | very ve... |
++----+------------+
+`
+	if out := string(tbl.Render(StyleGrid)); out != expect {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+}
+
+func TestClipAtWord(t *testing.T) {
+	tbl := New().ClipCell("…").ClipAtWord().MaxWidth(20)
+	tbl.Header([]string{"text"})
+	tbl.AddRow([]interface{}{"Curabitur ullamcorper ultricies nisi."}) // sentence
+	tbl.AddRow([]interface{}{"ACGTACGTACGTACGTACGTACGTACGT"})          // a single long token
+	tbl.AddRow([]interface{}{"Etiam rhoncus maecenas"})                // too much removed
+	tbl.AddRow([]interface{}{"谢谢，我很好，你呢？谢谢，我很好，你呢？"})                  // CJK
+	expect := []string{
+		"text",
+		"Curabitur…",
+		"ACGTACGTACGTACGTACG…",
+		"Etiam rhoncus…",
+		"谢谢，我很好，你呢…",
+	}
+	lines := strings.Split(strings.TrimSuffix(string(tbl.Render(StylePlain)), "\n"), "\n")
+	for i, line := range lines {
+		if got := strings.TrimRight(line, " "); got != expect[i] {
+			t.Errorf("expected %q, got %q", expect[i], got)
+		}
+	}
+
+	// a column option
+	tbl = New().ClipCell("...")
+	tbl.HeaderWithFormat([]Column{{Header: "a", MaxWidth: 10, ClipAtWord: true}, {Header: "b", MaxWidth: 10}})
+	tbl.AddRow([]interface{}{"abc defgh ijk", "abc defgh ijk"})
+	lines = strings.Split(strings.TrimSuffix(string(tbl.Render(StylePlain)), "\n"), "\n")
+	if lines[1] != "abc...       abc def..." {
+		t.Errorf("unexpected row: %q", lines[1])
+	}
+}
+
+func TestGlobalAndColumnMinWidth(t *testing.T) {
+	tbl := New().MinWidth(10).MaxWidth(30)
+	tbl.HeaderWithFormat([]Column{
+		{Header: "number", MinWidth: 5},
+		{Header: "name", MinWidth: 14},
+	})
+	tbl.AddRow([]interface{}{1, "Donec"})
+	tbl.checkWidths()
+	if w := tbl.maxWidths[0]; w != 10 { // the global MinWidth is larger
+		t.Errorf("unexpected width of the number column: %d", w)
+	}
+	if w := tbl.maxWidths[1]; w != 14 { // the column MinWidth is larger
+		t.Errorf("unexpected width of the name column: %d", w)
+	}
+}
+
+var errDiskFull = errors.New("disk full")
+
+type failedWriter struct{}
+
+func (w failedWriter) Write(p []byte) (int, error) {
+	return 0, errDiskFull
+}
+
+func TestWriterBufferAll(t *testing.T) {
+	addRows := func(tbl *Table) {
+		tbl.Header([]string{"id", "name"})
+		for i := 1; i <= 100; i++ {
+			tbl.AddRow([]interface{}{i, strings.Repeat("a", i%17)})
+		}
+	}
+
+	tbl := New()
+	addRows(tbl)
+	expect := tbl.Render(StyleGrid)
+
+	var buf bytes.Buffer
+	tbl = New().Style(StyleGrid)
+	if err := tbl.Writer(&buf, 0); err != nil {
+		t.Fatal(err)
+	}
+	addRows(tbl)
+	if buf.Len() > 0 {
+		t.Errorf("no data should be written before flushing:\n%s", buf.String())
+	}
+	if err := tbl.FlushE(); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(buf.Bytes(), expect) {
+		t.Errorf("unexpected output:\n%s", buf.String())
+	}
+
+	// errors of writing
+	tbl = New()
+	tbl.Writer(failedWriter{}, 0)
+	addRows(tbl)
+	if err := tbl.FlushE(); err == nil || err.Error() != "stable: writing table: disk full" {
+		t.Errorf("expected an error of writing, got %v", err)
+	}
+}
+
+var errBrokenPipe = errors.New("broken pipe")
+
+// limitedWriter fails after writing n bytes.
+type limitedWriter struct {
+	n      int
+	calls  int // the number of calls of Write
+	buffer bytes.Buffer
+}
+
+func (w *limitedWriter) Write(p []byte) (int, error) {
+	w.calls++
+	if w.buffer.Len()+len(p) > w.n {
+		k := w.n - w.buffer.Len()
+		w.buffer.Write(p[:k])
+		return k, errBrokenPipe
+	}
+	return w.buffer.Write(p)
+}
+
+func TestWriteError(t *testing.T) {
+	w := &limitedWriter{n: 100}
+	tbl := New()
+	tbl.Writer(w, 2)
+	tbl.Header([]string{"id", "name"})
+
+	var err error
+	var i int
+	for i = 1; i <= 100; i++ {
+		if err = tbl.AddRow([]interface{}{i, "alpha"}); err != nil {
+			break
+		}
+	}
+	if !errors.Is(err, errBrokenPipe) {
+		t.Fatalf("expected an error of writing, got %v", err)
+	}
+	calls := w.calls
+
+	// the error is sticky
+	if err = tbl.AddRow([]interface{}{i + 1, "beta"}); !errors.Is(err, errBrokenPipe) {
+		t.Errorf("expected the sticky error, got %v", err)
+	}
+	if err = tbl.AddSeparator(); !errors.Is(err, errBrokenPipe) {
+		t.Errorf("expected the sticky error, got %v", err)
+	}
+	if err = tbl.FlushE(); !errors.Is(err, errBrokenPipe) {
+		t.Errorf("expected the sticky error, got %v", err)
+	}
+	if w.calls != calls {
+		t.Errorf("data is written after an error")
+	}
+
+	// a short write without an error
+	tbl = New()
+	tbl.Writer(shortWriter{}, 1)
+	tbl.Header([]string{"id"})
+	tbl.AddRow([]interface{}{1})
+	tbl.Flush()
+	if err = tbl.Err(); !errors.Is(err, io.ErrShortWrite) {
+		t.Errorf("expected io.ErrShortWrite, got %v", err)
+	}
+}
+
+type shortWriter struct{}
+
+func (w shortWriter) Write(p []byte) (int, error) {
+	return len(p) / 2, nil
+}
+
+func TestPreserveHeader(t *testing.T) {
+	expect := `+----+------------------+
+| id | complete lineage |
++====+==================+
+| 1  | Bacteria;        |
+|    | Proteobacteria   |
++----+------------------+
+`
+	tbl := New().PreserveHeader().MaxWidth(8).WrapDelimiters(';', ' ')
+	tbl.Header([]string{"id", "complete lineage"})
+	tbl.AddRow([]interface{}{1, "Bacteria;Proteobacteria"})
+	if out := string(tbl.Render(StyleGrid)); out != expect {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+
+	// streaming
+	var buf bytes.Buffer
+	tbl = New().PreserveHeader().MaxWidth(8).WrapDelimiters(';', ' ')
+	tbl.Writer(&buf, 1)
+	tbl.Style(StyleGrid)
+	tbl.Header([]string{"id", "complete lineage"})
+	tbl.AddRow([]interface{}{1, "Bacteria;Proteobacteria"})
+	tbl.Flush()
+	if buf.String() != expect {
+		t.Errorf("unexpected output in streaming mode:\n%s", buf.String())
+	}
+}
+
+func TestWidthsCache(t *testing.T) {
+	tbl := New()
+	tbl.Header([]string{"id", "name"})
+	for i := 0; i < 100000; i++ {
+		tbl.AddRow([]interface{}{i, "abc"})
+	}
+	firstLine := func() string {
+		out := tbl.Render(StyleGrid)
+		return string(out[:bytes.IndexByte(out, '\n')])
+	}
+	if got := firstLine(); got != "+-------+------+" {
+		t.Fatalf("unexpected line: %s", got)
+	}
+
+	// the rows are not scanned again for an unchanged table,
+	// so a cell modified behind the back is wrapped.
+	tbl.rows[0][1] = "abc abc"
+	if got := firstLine(); got != "+-------+------+" {
+		t.Errorf("widths are recomputed for an unchanged table: %s", got)
+	}
+
+	// widths are updated after changes
+	tbl.AddRow([]interface{}{0, "abcdefgh"})
+	if got := firstLine(); got != "+-------+----------+" {
+		t.Errorf("widths are not updated after adding a row: %s", got)
+	}
+	tbl.UpdateCell(0, 1, "abcdefghij")
+	if got := firstLine(); got != "+-------+------------+" {
+		t.Errorf("widths are not updated after updating a cell: %s", got)
+	}
+	tbl.MaxWidth(5)
+	if got := firstLine(); got != "+-------+-------+" {
+		t.Errorf("widths are not updated after setting MaxWidth: %s", got)
+	}
+}
+
+func TestPadding(t *testing.T) {
+	tests := []struct {
+		padding string
+		expect  string
+	}{
+		{"", `+--+-----+
+|id|name |
++==+=====+
+|1 |alpha|
++--+-----+
+`},
+		{" ", `+----+-------+
+| id | name  |
++====+=======+
+| 1  | alpha |
++----+-------+
+`},
+		{"  ", `+------+---------+
+|  id  |  name   |
++======+=========+
+|  1   |  alpha  |
++------+---------+
+`},
+	}
+	for _, test := range tests {
+		tbl := New().Padding(test.padding)
+		tbl.Header([]string{"id", "name"})
+		tbl.AddRow([]interface{}{1, "alpha"})
+		if got := string(tbl.Render(StyleGrid)); got != test.expect {
+			t.Errorf("padding %q: expected:\n%s\ngot:\n%s", test.padding, test.expect, got)
+		}
+
+		// streaming
+		var buf bytes.Buffer
+		tbl = New().Padding(test.padding)
+		tbl.Writer(&buf, 1)
+		tbl.Style(StyleGrid)
+		tbl.Header([]string{"id", "name"})
+		tbl.AddRow([]interface{}{1, "alpha"})
+		tbl.Flush()
+		if got := buf.String(); got != test.expect {
+			t.Errorf("padding %q in streaming mode: expected:\n%s\ngot:\n%s", test.padding, test.expect, got)
+		}
+	}
+}
+
+func TestTrimTrailingSpaces(t *testing.T) {
+	newTable := func(trim bool) *Table {
+		tbl := New()
+		if trim {
+			tbl.TrimTrailingSpaces()
+		}
+		tbl.Header([]string{"id", "name"})
+		tbl.AddRow([]interface{}{1, "alpha"})
+		tbl.AddRow([]interface{}{2, "b"})
+		tbl.AddRowValues("", "")
+		return tbl
+	}
+
+	for _, style := range []*TableStyle{StylePlain, StyleSimple, StyleLight} {
+		for _, line := range strings.Split(string(newTable(true).Render(style)), "\n") {
+			if strings.HasSuffix(line, " ") {
+				t.Errorf("style %s: trailing spaces in line %q", style.Name, line)
+			}
+		}
+	}
+
+	// bordered styles are not affected
+	if got, expect := string(newTable(true).Render(StyleGrid)), string(newTable(false).Render(StyleGrid)); got != expect {
+		t.Errorf("bordered style affected:\n%s", got)
+	}
+
+	// streaming
+	var buf bytes.Buffer
+	tbl := New().TrimTrailingSpaces()
+	tbl.Writer(&buf, 1)
+	tbl.Header([]string{"id", "name"})
+	tbl.AddRow([]interface{}{1, "alpha"})
+	tbl.AddRow([]interface{}{2, "b"})
+	tbl.Flush()
+	if got, expect := buf.String(), "id   name\n1    alpha\n2    b\n"; got != expect {
+		t.Errorf("expected %q, got %q", expect, got)
+	}
+}
+
+func TestWrapGraphemeClusters(t *testing.T) {
+	coder, flag := "👩‍💻", "🇫🇷"
+	tests := []struct {
+		text   string
+		width  int
+		hyphen rune
+		expect []string
+	}{
+		{"abc" + coder + "d", 4, 0, []string{"abc", coder + "d"}},
+		{"ab" + coder + "cd", 3, 0, []string{"ab", coder + "c", "d"}},
+		{"abcd" + flag + "e", 4, 0, []string{"abcd", flag + "e"}},
+		{"abc" + coder + "def", 4, '-', []string{"abc-", coder + "d-", "ef"}},
+		{"cafe\u0301s", 4, 0, []string{"cafe\u0301", "s"}}, // a combining accent
+	}
+	for _, test := range tests {
+		lines, _ := wrapText(nil, test.text, test.width, " ", test.hyphen)
+		if !reflect.DeepEqual(lines, test.expect) {
+			t.Errorf("wrap %q: expected %q, got %q", test.text, test.expect, lines)
+		}
+	}
+
+	// clipping
+	tbl := New().MaxWidth(4).ClipCell("")
+	tbl.Header([]string{"text"})
+	tbl.AddRow([]interface{}{"abc" + coder})
+	if got := strings.Split(string(tbl.Render(StylePlain)), "\n")[1]; got != "abc " {
+		t.Errorf("unexpected clipped cell: %q", got)
+	}
+}
+
+func TestWrapMultiByteRandom(t *testing.T) {
+	// a wide delimiter exceeding the width
+	if lines, err := wrapText(nil, "abc，def", 4, " ，", 0); err != nil || !reflect.DeepEqual(lines, []string{"abc", "，de", "f"}) {
+		t.Errorf("unexpected lines: %q, %v", lines, err)
+	}
+
+	pieces := []string{"a", "b", " ", "，", "沈", "伟", "é", "é", "👩‍💻", "🇫🇷", "ß", "😀"}
+	r := rand.New(rand.NewSource(1))
+	random := func() string {
+		var b strings.Builder
+		for i := r.Intn(30); i >= 0; i-- {
+			b.WriteString(pieces[r.Intn(len(pieces))])
+		}
+		return b.String()
+	}
+
+	for k := 0; k < 1000; k++ {
+		text := random()
+		for w := 1; w <= 10; w++ {
+			for _, clip := range []bool{false, true} {
+				tbl := New().WrapDelimiters(' ', '，')
+				if clip {
+					tbl.ClipCell("…")
+				}
+				tbl.maxWidths = []int{w}
+
+				tbl.formatRow([]string{text})
+				var lines []string
+				for _, row := range tbl.wrappedRow {
+					lines = append(lines, (*row)[0])
+				}
+				if len(tbl.wrappedRow) == 0 {
+					lines = []string{text}
+				}
+				for _, line := range lines {
+					if !utf8.ValidString(line) {
+						t.Fatalf("width %d, clip %v: invalid UTF-8 in %q of %q", w, clip, line, text)
+					}
+				}
+				// wide characters are dropped in a column of width 1
+				if !clip && w > 1 && strings.Join(lines, "") != text {
+					t.Fatalf("width %d: text changed after wrapping: %q -> %q", w, text, lines)
+				}
+				if err := tbl.Err(); err != nil {
+					t.Fatalf("width %d, clip %v: %s: %q", w, clip, err, text)
+				}
+			}
+		}
+	}
+}
+
+func TestLinePrefix(t *testing.T) {
+	// every line of the table without a prefix is prefixed
+	check := func(name string, out, expect []byte, prefix string) {
+		lines := strings.Split(strings.TrimSuffix(string(out), "\n"), "\n")
+		expectLines := strings.Split(strings.TrimSuffix(string(expect), "\n"), "\n")
+		if len(lines) != len(expectLines) {
+			t.Fatalf("%s: expected %d lines, got %d:\n%s", name, len(expectLines), len(lines), out)
+		}
+		for i, line := range lines {
+			if line != prefix+expectLines[i] {
+				t.Errorf("%s: expected %q, got %q", name, prefix+expectLines[i], line)
+			}
+		}
+	}
+
+	newTable := func(prefix string) *Table {
+		tbl := New().LinePrefix(prefix).MaxWidth(5).Caption("a caption")
+		tbl.Header([]string{"id", "name"})
+		tbl.AddRow([]interface{}{1, "alpha beta"})
+		tbl.AddRow([]interface{}{2, "gamma"})
+		return tbl
+	}
+
+	check("render", newTable("    | ").Render(StyleGrid), newTable("").Render(StyleGrid), "    | ")
+
+	// streaming
+	stream := func(prefix string) []byte {
+		var buf bytes.Buffer
+		tbl := New().LinePrefix(prefix).MaxWidth(5)
+		tbl.Writer(&buf, 1)
+		tbl.Style(StyleGrid)
+		tbl.Header([]string{"id", "name"})
+		tbl.AddRow([]interface{}{1, "alpha beta"})
+		tbl.AddSeparator()
+		tbl.AddRow([]interface{}{2, "gamma"})
+		tbl.Flush()
+		return buf.Bytes()
+	}
+	check("streaming", stream("    "), stream(""), "    ")
+
+	// not counted in the total width
+	tbl := newTable("    | ").MaxTotalWidth(16)
+	if widths, err := tbl.ColumnWidths(StyleGrid); err != nil || !reflect.DeepEqual(widths, []int{2, 5}) {
+		t.Errorf("unexpected widths: %v, %v", widths, err)
+	}
+
+	// but subtracted from the terminal width
+	t.Setenv("COLUMNS", "19")
+	tbl = newTable("    | ").FitTerminal()
+	if widths, err := tbl.ColumnWidths(StyleGrid); err != nil || !reflect.DeepEqual(widths, []int{2, 4}) {
+		t.Errorf("unexpected widths: %v, %v", widths, err)
+	}
+}
+
+func TestLineHook(t *testing.T) {
+	addRows := func(tbl *Table) {
+		tbl.Header([]string{"id", "name"})
+		tbl.AddRow([]interface{}{1, "apple"})
+		tbl.AddRow([]interface{}{2, "banana"})
+		tbl.AddRow([]interface{}{3, "cherry"})
+	}
+	logHook := func(line []byte) []byte {
+		return append([]byte("LOG: "), line...)
+	}
+	expect := `LOG: +----+--------+
+LOG: | id | name   |
+LOG: +====+========+
+LOG: | 1  | apple  |
+LOG: +----+--------+
+LOG: | 2  | banana |
+LOG: +----+--------+
+LOG: | 3  | cherry |
+LOG: +----+--------+
+`
+
+	tbl := New().LineHook(logHook)
+	addRows(tbl)
+	if out := string(tbl.Render(StyleGrid)); out != expect {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+
+	// streaming
+	var buf bytes.Buffer
+	tbl = New().LineHook(logHook)
+	tbl.Writer(&buf, 2)
+	tbl.Style(StyleGrid)
+	addRows(tbl)
+	tbl.Flush()
+	if buf.String() != expect {
+		t.Errorf("unexpected output:\n%s", buf.String())
+	}
+
+	// dropping border lines, after the line prefix is added
+	tbl = New().LinePrefix("  ").LineHook(func(line []byte) []byte {
+		if bytes.HasPrefix(line, []byte("  +")) {
+			return nil
+		}
+		return line
+	})
+	addRows(tbl)
+	expect = `  | id | name   |
+  | 1  | apple  |
+  | 2  | banana |
+  | 3  | cherry |
+`
+	if out := string(tbl.Render(StyleGrid)); out != expect {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+}
+
+func TestClipWideCharacters(t *testing.T) {
+	tests := []struct {
+		mark      string
+		width     int
+		cell      string
+		widthFunc bool
+		expect    string
+	}{
+		{"＊", 2, "abcdefgh", false, "＊"}, // a wide mark
+		{"＊", 3, "沈伟沈伟", false, "＊ "},    // no room for the wide character before the mark
+		{"＊", 4, "沈伟沈伟", false, "沈＊"},    // a wide mark after a wide character
+		{"", 3, "沈伟沈伟", false, "沈 "},     // an empty mark
+		{"…", 4, "ab沈伟", false, "ab… "},  // the wide character at the boundary is dropped
+		{"…", 2, "沈伟", true, "沈"},        // the mark does not fit after a wide character
+		{"", 1, "沈伟", true, " "},         // no room for a wide character
+		{"…", 5, "abc沈伟", true, "abc… "}, // the wide character at the boundary is dropped
+	}
+	for _, test := range tests {
+		tbl := New().ClipCell(test.mark)
+		if test.widthFunc {
+			tbl.WidthFunc(runewidth.StringWidth)
+		}
+		tbl.HeaderWithFormat([]Column{{Header: "a", MaxWidth: test.width}})
+		tbl.AddRow([]interface{}{test.cell})
+		lines := strings.Split(string(tbl.Render(StylePlain)), "\n")
+		if lines[1] != test.expect {
+			t.Errorf("mark %q, width %d, cell %q: expected %q, got %q", test.mark, test.width, test.cell, test.expect, lines[1])
+		}
+	}
+}
+
+func TestStreamingWideCells(t *testing.T) {
+	cells := []string{
+		strings.Repeat("abcdefghij", 30),
+		strings.Repeat("沈伟 ab", 50),
+		strings.Repeat("x ", 150),
+	}
+	for _, clip := range []bool{false, true} {
+		for _, style := range []*TableStyle{StylePlain, StyleGrid} {
+			var buf bytes.Buffer
+			tbl := New()
+			if clip {
+				tbl.ClipCell("…")
+			}
+			tbl.Writer(&buf, 1)
+			tbl.Style(style)
+			tbl.Header([]string{"+", "text"})
+			tbl.AddRow([]interface{}{"-", "short"})
+			for _, cell := range cells {
+				tbl.AddRow([]interface{}{"沈伟", cell})
+			}
+			tbl.Flush()
+
+			lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+			w := runewidth.StringWidth(lines[0])
+			for _, line := range lines {
+				if runewidth.StringWidth(line) != w {
+					t.Errorf("clip %v, style %s: misaligned line %q", clip, style.Name, line)
+				}
+			}
+		}
+	}
+}
+
+func TestLineEndings(t *testing.T) {
+	tests := []struct {
+		cell   string
+		expect []string
+	}{
+		{"a\r\nb", []string{"a", "b"}},                 // CRLF
+		{"a\rb", []string{"a", "b"}},                   // lone CR
+		{"a\r\nb\rc\nd", []string{"a", "b", "c", "d"}}, // mixed
+		{"a\r\n\r\nb", []string{"a", "", "b"}},
+	}
+	for _, test := range tests {
+		for _, strs := range []bool{false, true} {
+			tbl := New().Convert(nil)
+			tbl.Header([]string{"text"})
+			if strs {
+				tbl.AddRowStringSlice([]string{test.cell})
+			} else {
+				tbl.AddRow([]interface{}{test.cell})
+			}
+			lines := strings.Split(strings.TrimSuffix(string(tbl.Render(StylePlain)), "\n"), "\n")[1:]
+			for i := range lines {
+				lines[i] = strings.TrimRight(lines[i], " ")
+			}
+			if !reflect.DeepEqual(lines, test.expect) {
+				t.Errorf("cell %q: expected %q, got %q", test.cell, test.expect, lines)
+			}
+		}
+	}
+
+	// the default conversion table
+	tbl := New()
+	tbl.Header([]string{"text"})
+	tbl.AddRow([]interface{}{"a\r\nb\rc"})
+	if got := tbl.rows[0][0]; got != "a b c" {
+		t.Errorf("unexpected cell: %q", got)
+	}
+
+	tbl = New().Convert(nil).KeepCarriageReturns()
+	tbl.Header([]string{"text"})
+	tbl.AddRow([]interface{}{"a\r\nb"})
+	if got := tbl.rows[0][0]; got != "a\r\nb" {
+		t.Errorf("unexpected cell: %q", got)
+	}
+}
+
+func TestSingleLine(t *testing.T) {
+	newTable := func() *Table {
+		tbl := New().Convert(nil)
+		tbl.Header([]string{"id", "text"})
+		tbl.AddRow([]interface{}{1, "first line\nsecond line"})
+		tbl.AddRow([]interface{}{2, "a\nb"})
+		return tbl
+	}
+
+	tests := []struct {
+		tbl    *Table
+		expect string
+	}{
+		{newTable().SingleLine(), `id   text                  
+1    first line↵second line
+2    a↵b                   
+`},
+		{newTable().SingleLine().NewlineReplacement(`\n`).ClipCell("…").MaxWidth(12), `id   text        
+1    first line\…
+2    a\nb        
+`},
+		{newTable().ClipCell("…").NewlineReplacement(" | ").MaxWidth(12), `id   text        
+1    first line …
+2    a | b       
+`},
+		// each line is clipped without a replacement
+		{newTable().ClipCell("…").MaxWidth(8), `id   text    
+1    first l…
+     second …
+2    a       
+     b       
+`},
+	}
+	for i, test := range tests {
+		out := string(test.tbl.Render(StylePlain))
+		if out != test.expect {
+			t.Errorf("case %d: expected:\n%s\ngot:\n%s", i+1, test.expect, out)
+		}
+		lines := strings.Split(strings.TrimSuffix(out, "\n"), "\n")
+		for _, line := range lines {
+			if runewidth.StringWidth(line) != runewidth.StringWidth(lines[0]) {
+				t.Errorf("case %d: misaligned line %q", i+1, line)
+			}
+		}
+	}
+}
+
+func TestAsymmetricPadding(t *testing.T) {
+	tbl := New().PaddingLeft(" ").PaddingRight("")
+	tbl.Header([]string{"id", "value"})
+	tbl.AddRow([]interface{}{1, 1000})
+	tbl.AddRow([]interface{}{22, 3})
+	tbl.AlignRight()
+	expect := `+---+------+
+| id| value|
++===+======+
+|  1|  1000|
++---+------+
+| 22|     3|
++---+------+
+`
+	out := string(tbl.Render(StyleGrid))
+	if out != expect {
+		t.Errorf("expected:\n%s\ngot:\n%s", expect, out)
+	}
+
+	// the left padding of the style is kept
+	tbl = New().PaddingRight("   ")
+	tbl.Header([]string{"id", "value"})
+	tbl.AddRow([]interface{}{1, 1000})
+	tbl.Caption("a caption")
+	lines := strings.Split(strings.TrimSuffix(string(tbl.Render(StyleLight)), "\n"), "\n")
+	for _, line := range lines[:len(lines)-1] {
+		if runewidth.StringWidth(line) != runewidth.StringWidth(lines[0]) {
+			t.Errorf("misaligned line %q", line)
+		}
+	}
+	if lines[1] != "| id   | value   |" {
+		t.Errorf("unexpected header: %q", lines[1])
+	}
+}
+
+func TestEstimateWidths(t *testing.T) {
+	for _, clip := range []bool{false, true} {
+		tbl := New().EstimateWidths(5)
+		if clip {
+			tbl.ClipCell("…")
+		}
+		tbl.Header([]string{"id", "name"})
+		for i := 1; i <= 10; i++ {
+			tbl.AddRow([]interface{}{i, "short"})
+		}
+		tbl.AddRow([]interface{}{11, "a much longer name 沈伟"})
+		tbl.AddRow([]interface{}{1000000, "x"})
+
+		widths, _ := tbl.ColumnWidths(StyleGrid)
+		if !reflect.DeepEqual(widths, []int{2, 5}) {
+			t.Errorf("unexpected estimated widths: %v", widths)
+		}
+
+		lines := strings.Split(strings.TrimSuffix(string(tbl.Render(StyleGrid)), "\n"), "\n")
+		for _, line := range lines {
+			if runewidth.StringWidth(line) != runewidth.StringWidth(lines[0]) {
+				t.Errorf("clip %v: line exceeding the estimated widths: %q", clip, line)
+			}
+		}
+	}
+
+	tbl := New().EstimateWidths(5)
+	tbl.Header([]string{"id"})
+	tbl.AddRow([]interface{}{1})
+	tbl.AddRow([]interface{}{1000})
+	if widths, _ := tbl.ColumnWidths(StyleGrid); widths[0] != 4 {
+		t.Errorf("all rows should be used for a small table: %v", widths)
+	}
+}
+
+func benchmarkFirstPage(b *testing.B, sampleRows int) {
+	tbl := New().EstimateWidths(sampleRows)
+	tbl.Header([]string{"id", "name", "seq", "score"})
+	tbl.AddRowsFromSlices(benchmarkRows(1000000))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		tbl.widthsChecked = false
+		tbl.RenderRows(StylePlain, 0, 100)
+	}
+}
+
+func BenchmarkFirstPageExactWidths(b *testing.B) {
+	benchmarkFirstPage(b, 0)
+}
+
+func BenchmarkFirstPageEstimatedWidths(b *testing.B) {
+	benchmarkFirstPage(b, 1000)
+}
+
+// nthFailedWriter fails on the nth call of Write.
+type nthFailedWriter struct {
+	n     int
+	calls int
+}
+
+func (w *nthFailedWriter) Write(p []byte) (int, error) {
+	w.calls++
+	if w.calls >= w.n {
+		return 0, errBrokenPipe
+	}
+	return len(p), nil
+}
+
+func TestAddRowWriteError(t *testing.T) {
+	w := &nthFailedWriter{n: 3}
+	tbl := New()
+	tbl.Writer(w, 1)
+	tbl.Header([]string{"id"})
+
+	// the 1st row is buffered, the 2nd one triggers writing the header and buffered rows,
+	// the 3rd and 4th ones are written directly.
+	for i := 1; i <= 3; i++ {
+		if err := tbl.AddRow([]interface{}{i}); err != nil {
+			t.Fatalf("unexpected error in row %d: %s", i, err)
+		}
+	}
+	err := tbl.AddRow([]interface{}{4})
+	if !errors.Is(err, errBrokenPipe) || err.Error() != "stable: writing data row: broken pipe" {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err2 := tbl.AddRow([]interface{}{5}); err2 != err {
+		t.Errorf("expected the same error, got %v", err2)
+	}
+	if w.calls != 3 {
+		t.Errorf("data is written after an error: %d calls", w.calls)
+	}
+
+	// failing in writing the header
+	w = &nthFailedWriter{n: 1}
+	tbl = New()
+	tbl.Writer(w, 1)
+	tbl.Header([]string{"id"})
+	tbl.AddRow([]interface{}{1})
+	if err = tbl.AddRow([]interface{}{2}); err == nil || err.Error() != "stable: writing header and buffered rows: broken pipe" {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestNextTable(t *testing.T) {
+	addRows := func(tbl *Table, k int) {
+		for i := 1; i <= 5; i++ {
+			tbl.AddRow([]interface{}{i, strings.Repeat("ab", i*k)})
+		}
+		tbl.AddSeparator()
+		tbl.AddRow([]interface{}{100, "c"})
+	}
+	newTable := func(buf *bytes.Buffer) *Table {
+		tbl := New().ShowRowNumbers("#").Caption("a caption")
+		tbl.Writer(buf, 2)
+		tbl.Style(StyleGrid)
+		tbl.Header([]string{"id", "text"})
+		tbl.Summary(map[string]Aggregate{"id": AggregateSum})
+		return tbl
+	}
+
+	var expect, buf bytes.Buffer
+	for k := 1; k <= 2; k++ {
+		tbl := newTable(&expect)
+		addRows(tbl, k)
+		tbl.Flush()
+	}
+
+	tbl := newTable(&buf)
+	if err := tbl.NextTable(); err != ErrNextTableBeforeFlush {
+		t.Errorf("expected ErrNextTableBeforeFlush, got %v", err)
+	}
+	addRows(tbl, 1)
+	tbl.Flush()
+	if err := tbl.AddRow([]interface{}{1, "a"}); err != ErrAddRowAfterFlush {
+		t.Errorf("expected ErrAddRowAfterFlush, got %v", err)
+	}
+	if err := tbl.NextTable(); err != nil {
+		t.Fatal(err)
+	}
+	addRows(tbl, 2)
+	tbl.Flush()
+
+	if buf.String() != expect.String() {
+		t.Errorf("expected:\n%s\ngot:\n%s", expect.String(), buf.String())
+	}
+}
+
+func TestConcurrent(t *testing.T) {
+	var buf bytes.Buffer
+	tbl := New().Concurrent()
+	tbl.Writer(&buf, 100)
+	tbl.Style(StyleGrid)
+	tbl.Header([]string{"worker", "row", "text"})
+
+	const nWorkers, nRows = 16, 1000
+	done := make(chan error, nWorkers)
+	for w := 0; w < nWorkers; w++ {
+		go func(w int) {
+			var err error
+			for i := 0; i < nRows && err == nil; i++ {
+				if i%2 == 0 {
+					err = tbl.AddRow([]interface{}{w, i, strings.Repeat("x", i%7)})
+				} else {
+					err = tbl.AddRowStringSlice([]string{strconv.Itoa(w), strconv.Itoa(i), "y"})
+				}
+			}
+			done <- err
+		}(w)
+	}
+	for w := 0; w < nWorkers; w++ {
+		if err := <-done; err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tbl.FlushE(); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	var nData int
+	for _, line := range lines {
+		if len(line) != len(lines[0]) {
+			t.Fatalf("broken line: %q", line)
+		}
+		if strings.HasPrefix(line, "| ") {
+			nData++
+		}
+	}
+	if nData != nWorkers*nRows+1 { // with the header
+		t.Errorf("expected %d data lines, got %d", nWorkers*nRows+1, nData)
+	}
+}
+
+func TestFlushTwice(t *testing.T) {
+	var buf bytes.Buffer
+	tbl := New()
+	tbl.Writer(&buf, 10)
+	tbl.Header([]string{"id", "name"})
+	tbl.AddRow([]interface{}{1, "a"})
+	if err := tbl.FlushE(); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	tbl.Flush()
+	if err := tbl.FlushE(); err != ErrAlreadyFlushed {
+		t.Errorf("expected ErrAlreadyFlushed, got %v", err)
+	}
+	if buf.String() != out {
+		t.Errorf("the table is written more than once:\n%s", buf.String())
+	}
+
+	// after the buffered rows are written
+	buf.Reset()
+	tbl = New()
+	tbl.Writer(&buf, 1)
+	tbl.Header([]string{"id", "name"})
+	tbl.AddRow([]interface{}{1, "a"})
+	tbl.AddRow([]interface{}{2, "b"})
+	tbl.Flush()
+	out = buf.String()
+	tbl.Flush()
+	if buf.String() != out {
+		t.Errorf("the bottom line is written more than once:\n%s", buf.String())
+	}
+}
+
+func TestHeaderOnly(t *testing.T) {
+	tests := []struct {
+		style  *TableStyle
+		expect string
+	}{
+		{StyleGrid, `+----+------+
+| id | name |
++----+------+
+`},
+		{StylePlain, "id   name\n"},
+		{StyleSimple, `-----------
+ id   name 
+-----------
+`},
+	}
+	for _, test := range tests {
+		var buf bytes.Buffer
+		tbl := New()
+		tbl.Writer(&buf, 10)
+		tbl.Style(test.style)
+		tbl.Header([]string{"id", "name"})
+		tbl.Flush()
+		if buf.String() != test.expect {
+			t.Errorf("style %s: expected:\n%s\ngot:\n%s", test.style.Name, test.expect, buf.String())
+		}
+
+		tbl = New()
+		tbl.Header([]string{"id", "name"})
+		if out := string(tbl.Render(test.style)); out != test.expect {
+			t.Errorf("style %s: expected:\n%s\ngot:\n%s", test.style.Name, test.expect, out)
+		}
+	}
+}
+
+func TestSetWriter(t *testing.T) {
+	addRows := func(tbl *Table, from, to int) {
+		for i := from; i <= to; i++ {
+			tbl.AddRow([]interface{}{i, "a"})
+		}
+	}
+	expected := New()
+	expected.Header([]string{"id", "name"})
+	addRows(expected, 1, 5)
+	expect := string(expected.Render(StyleGrid))
+
+	// replacing the writer before data is written
+	var buf1, buf2 bytes.Buffer
+	tbl := New().Style(StyleGrid)
+	tbl.Header([]string{"id", "name"})
+	if err := tbl.SetWriter(&buf1, 10); err != nil {
+		t.Fatal(err)
+	}
+	addRows(tbl, 1, 2)
+	if err := tbl.Writer(&buf2, 3); err != ErrWriterRepeatedlySet {
+		t.Errorf("expected ErrWriterRepeatedlySet, got %v", err)
+	}
+	if err := tbl.SetWriter(&buf2, 3); err != nil {
+		t.Fatal(err)
+	}
+	addRows(tbl, 3, 5)
+	tbl.Flush()
+	if buf1.Len() != 0 || buf2.String() != expect {
+		t.Errorf("unexpected output:\n%s\n%s", buf1.String(), buf2.String())
+	}
+
+	// rejected after data is written
+	if err := tbl.SetWriter(&buf1, 3); !errors.Is(err, ErrWriterInUse) {
+		t.Errorf("expected ErrWriterInUse, got %v", err)
+	}
+	if err := tbl.DetachWriter(); !errors.Is(err, ErrWriterInUse) {
+		t.Errorf("expected ErrWriterInUse, got %v", err)
+	}
+
+	buf1.Reset()
+	tbl = New()
+	tbl.Writer(&buf1, 1)
+	tbl.Header([]string{"id", "name"})
+	addRows(tbl, 1, 2) // the first row is written
+	if err := tbl.SetWriter(&buf2, 3); !errors.Is(err, ErrWriterInUse) {
+		t.Errorf("expected ErrWriterInUse, got %v", err)
+	}
+
+	// detaching the writer
+	buf1.Reset()
+	tbl = New()
+	tbl.Writer(&buf1, 10)
+	tbl.Header([]string{"id", "name"})
+	addRows(tbl, 1, 3)
+	if err := tbl.DetachWriter(); err != nil {
+		t.Fatal(err)
+	}
+	addRows(tbl, 4, 5)
+	if out := string(tbl.Render(StyleGrid)); out != expect || buf1.Len() != 0 {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+	if err := tbl.DetachWriter(); err != nil {
+		t.Errorf("detaching twice should be allowed: %v", err)
+	}
+
+	// fewer rows than the buffered ones
+	buf1.Reset()
+	tbl = New().Style(StyleGrid)
+	tbl.Header([]string{"id", "name"})
+	addRows(tbl, 1, 3)
+	tbl.SetWriter(&buf1, 1)
+	addRows(tbl, 4, 5)
+	tbl.Flush()
+	if buf1.String() != expect {
+		t.Errorf("unexpected output:\n%s", buf1.String())
+	}
+}
+
+// countingWriter counts the calls of Write.
+type countingWriter struct {
+	calls int
+	bytes.Buffer
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	w.calls++
+	return w.Buffer.Write(p)
+}
+
+func TestStreamingWrites(t *testing.T) {
+	w := &countingWriter{}
+	tbl := New().MaxWidth(5).ShowRowNumbers("#")
+	tbl.Writer(w, 10)
+	tbl.Style(StyleGrid)
+	tbl.Header([]string{"id", "text"})
+	for i := 1; i <= 100; i++ {
+		tbl.AddRow([]interface{}{i, "wrapped into lines"})
+		if i%20 == 0 {
+			tbl.AddSeparator()
+		}
+	}
+	tbl.Flush()
+
+	// one for the buffered rows, 89 for the other rows along with separators,
+	// and one for the bottom line
+	if w.calls != 1+89+1 {
+		t.Errorf("unexpected number of writes: %d", w.calls)
+	}
+
+	// a buffered writer is flushed
+	var buf bytes.Buffer
+	bw := bufio.NewWriter(&buf)
+	tbl = New()
+	tbl.Writer(bw, 1)
+	tbl.Header([]string{"id"})
+	tbl.AddRow([]interface{}{1})
+	tbl.AddRow([]interface{}{2})
+	if buf.Len() != 0 {
+		t.Errorf("data should be buffered")
+	}
+	tbl.Flush()
+	if buf.String() != "id\n1 \n2 \n" {
+		t.Errorf("unexpected output: %q", buf.String())
+	}
+}
+
+func BenchmarkStreamingWrites(b *testing.B) {
+	rows := benchmarkRows(10000)
+	b.ReportAllocs()
+	b.ResetTimer()
+	var calls int
+	for i := 0; i < b.N; i++ {
+		w := &countingWriter{}
+		tbl := New().MaxWidth(6)
+		tbl.Writer(w, 100)
+		tbl.Style(StyleGrid)
+		tbl.Header([]string{"id", "name", "seq", "score"})
+		for _, row := range rows {
+			tbl.AddRowStringSlice(row)
+		}
+		tbl.Flush()
+		calls += w.calls
+	}
+	b.ReportMetric(float64(calls)/float64(b.N), "writes/op")
+}
+
+func TestOnRow(t *testing.T) {
+	// memory mode
+	var counts []int
+	tbl := New().OnRow(func(n int) { counts = append(counts, n) })
+	tbl.Header([]string{"id"})
+	for i := 1; i <= 5; i++ {
+		tbl.AddRow([]interface{}{i})
+	}
+	if len(counts) != 5 || counts[4] != 5 {
+		t.Errorf("unexpected counts: %v", counts)
+	}
+
+	// streaming mode, with rows wrapped into multiple lines
+	var buf bytes.Buffer
+	counts = counts[:0]
+	tbl = New().MaxWidth(4).OnRow(func(n int) {
+		counts = append(counts, n)
+	})
+	tbl.Writer(&buf, 3)
+	tbl.Style(StyleGrid)
+	tbl.Header([]string{"id", "text"})
+	for i := 1; i <= 10; i++ {
+		tbl.AddRow([]interface{}{i, "a long text"})
+		if i == 2 && len(counts) != 0 {
+			t.Errorf("buffered rows should not be reported before written: %v", counts)
+		}
+	}
+	tbl.Flush()
+	if len(counts) != 10 {
+		t.Errorf("unexpected number of calls: %d", len(counts))
+	}
+	for i, n := range counts {
+		if n != i+1 {
+			t.Errorf("unexpected counts: %v", counts)
+			break
+		}
+	}
+
+	// all rows are written by Flush, and omitted rows are not reported
+	counts = counts[:0]
+	tbl = New().MaxRows(3).OnRow(func(n int) { counts = append(counts, n) })
+	tbl.Writer(&buf, 10)
+	for i := 1; i <= 5; i++ {
+		tbl.AddRow([]interface{}{i})
+	}
+	tbl.Flush()
+	if len(counts) != 3 {
+		t.Errorf("unexpected counts: %v", counts)
+	}
+
+	// a panicking callback
+	buf.Reset()
+	tbl = New().OnRow(func(n int) {
+		if n == 2 {
+			panic("oops")
+		}
+	})
+	tbl.Writer(&buf, 1)
+	tbl.Header([]string{"id"})
+	addRow := func(i int) {
+		defer func() { recover() }()
+		tbl.AddRow([]interface{}{i})
+	}
+	for i := 1; i <= 4; i++ {
+		addRow(i)
+	}
+	tbl.Flush()
+	if buf.String() != "id\n1 \n2 \n3 \n4 \n" {
+		t.Errorf("unexpected output: %q", buf.String())
+	}
+}
+
+func TestWriterAdaptive(t *testing.T) {
+	var buf bytes.Buffer
+	var written int
+	tbl := New().OnRow(func(n int) { written = n })
+	if err := tbl.WriterAdaptive(&buf, 2, 100, 2); err != nil {
+		t.Error(err)
+		return
+	}
+	tbl.Header([]string{"id", "text"})
+	// the widths stabilize at row 3
+	texts := []string{"a", "bbb", "ccccc", "dd", "e", "fffffffff", "g"}
+	for i, s := range texts {
+		tbl.AddRow([]interface{}{i + 1, s})
+		switch i + 1 {
+		case 4:
+			if written != 0 {
+				t.Errorf("rows should be buffered")
+			}
+		case 5:
+			if written != 5 {
+				t.Errorf("buffered rows should be written after the widths are stable: %d", written)
+			}
+		}
+	}
+	tbl.Flush()
+	expect := `id   text 
+1    a    
+2    bbb  
+3    ccccc
+4    dd   
+5    e    
+6    fffff
+     ffff 
+7    g    
+`
+	if buf.String() != expect {
+		t.Errorf("unexpected output:\n%s", buf.String())
+	}
+
+	// the widths never stabilize
+	buf.Reset()
+	written = 0
+	tbl = New().OnRow(func(n int) { written = n })
+	tbl.WriterAdaptive(&buf, 2, 5, 2)
+	text := ""
+	for i := 1; i <= 8; i++ {
+		text += "x"
+		tbl.AddRow([]interface{}{text})
+		if i <= 5 && written != 0 {
+			t.Errorf("rows should be buffered until maxRows rows are buffered")
+		}
+		if i > 5 && written != i {
+			t.Errorf("rows should be written after maxRows rows are buffered")
+		}
+	}
+	tbl.Flush()
+
+	if err := tbl.WriterAdaptive(&buf, 1, 1, 1); err != ErrWriterRepeatedlySet {
+		t.Errorf("expect ErrWriterRepeatedlySet")
+	}
+
+	// detaching the writer turns off the adaptive buffering
+	buf.Reset()
+	written = 0
+	tbl = New().OnRow(func(n int) { written = n })
+	tbl.WriterAdaptive(&buf, 1, 100, 1)
+	tbl.AddRow([]interface{}{"x"})
+	if err := tbl.DetachWriter(); err != nil {
+		t.Fatal(err)
+	}
+	if tbl.adaptive {
+		t.Errorf("the adaptive buffering should be turned off")
+	}
+	tbl.SetWriter(&buf, 3)
+	for i := 2; i <= 4; i++ {
+		tbl.AddRow([]interface{}{"x"})
+		if i <= 3 && written != 0 {
+			t.Errorf("rows should be buffered until bufRows rows are buffered: %d", written)
+		}
+	}
+	if written != 4 || !strings.HasPrefix(buf.String(), "x\nx\nx\nx\n") {
+		t.Errorf("rows should be written after bufRows rows are buffered:\n%s", buf.String())
+	}
+}
+
+func TestStreamingLikeRender(t *testing.T) {
+	styles := []*TableStyle{StylePlain, StyleSimple, StyleThreeLine, StyleGrid, StyleLight, StyleRound, StyleBold, StyleDouble}
+	build := func(tbl *Table, header bool, n int) {
+		if header {
+			tbl.Header([]string{"id", "name"})
+		}
+		for i := 1; i <= n; i++ {
+			tbl.AddRow([]interface{}{fmt.Sprintf("%02d", i), "abcde"})
+			switch i % 5 {
+			case 2:
+				tbl.AddSeparator()
+			case 4:
+				tbl.AddSection("section")
+			}
+		}
+	}
+	options := []func() *Table{
+		func() *Table { return New() },
+		func() *Table { return New().ShowRowNumbers("#") },
+		func() *Table { return New().EmphasizeSeparators() },
+		func() *Table { return New().RepeatHeaderEvery(2) },
+		func() *Table { return New().Caption("caption") },
+		func() *Table { return New().MaxRows(2) },
+		func() *Table { return New().Zebra(func(line string) string { return "*" + line }) },
+		func() *Table { return New().TrimTrailingSpaces().LinePrefix("  ") },
+	}
+	for o, newTable := range options {
+		for _, style := range styles {
+			for _, header := range []bool{true, false} {
+				for _, n := range []int{1, 2, 3, 4, 7} {
+					tbl := newTable()
+					build(tbl, header, n)
+					expect := string(tbl.Render(style))
+
+					for _, bufRows := range []uint{1, 2, 3, 5, 10} {
+						if o == 1 && n > int(bufRows) { // the width of row numbers is reserved, see TestRowNumbers
+							continue
+						}
+						var buf bytes.Buffer
+						tbl = newTable()
+						tbl.Writer(&buf, bufRows)
+						tbl.Style(style)
+						build(tbl, header, n)
+						tbl.Flush()
+						if buf.String() != expect {
+							t.Errorf("option: %d, style: %s, header: %v, rows: %d, bufRows: %d, expect:\n%s\nresult:\n%s",
+								o, style.Name, header, n, bufRows, expect, buf.String())
+						}
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestCheckpoint(t *testing.T) {
+	var buf bytes.Buffer
+	tbl := New().ShowRowNumbers("#")
+	if err := tbl.Checkpoint(); err != ErrCheckpointNotStreaming {
+		t.Errorf("expect ErrCheckpointNotStreaming")
+	}
+	tbl.Writer(&buf, 2)
+	tbl.Style(StyleGrid)
+	tbl.Header([]string{"id", "name"})
+	for i := 1; i <= 6; i++ {
+		tbl.AddRow([]interface{}{i, strings.Repeat("a", i)})
+		if i == 1 {
+			if err := tbl.Checkpoint(); err != ErrCheckpointNotStreaming {
+				t.Errorf("expect ErrCheckpointNotStreaming")
+			}
+		}
+		if i == 3 {
+			if err := tbl.Checkpoint(); err != nil {
+				t.Error(err)
+			}
+		}
+	}
+	tbl.Flush()
+	expect := `+--------+----+------+
+|      # | id | name |
++========+====+======+
+|      1 | 1  | a    |
++--------+----+------+
+|      2 | 2  | aa   |
++--------+----+------+
+|      3 | 3  | aaa  |
++--------+----+------+
++--------+----+------+
+|      # | id | name |
++========+====+======+
+|      4 | 4  | aaaa |
++--------+----+------+
+|      5 | 5  | aaaa |
+|        |    | a    |
++--------+----+------+
+|      6 | 6  | aaaa |
+|        |    | aa   |
++--------+----+------+
+`
+	if buf.String() != expect {
+		t.Errorf("unexpected output:\n%s", buf.String())
+	}
+
+	// all lines of the two blocks have the same width
+	for _, line := range strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n") {
+		if len(line) != 22 {
+			t.Errorf("unexpected line width: %q", line)
+		}
+	}
+
+	if err := tbl.Checkpoint(); err != ErrCheckpointNotStreaming {
+		t.Errorf("expect ErrCheckpointNotStreaming after Flush")
+	}
+}
+
+func TestBytesWritten(t *testing.T) {
+	var buf bytes.Buffer
+	tbl := New()
+	tbl.Writer(&buf, 1)
+	tbl.Header([]string{"id"})
+	for i := 1; i <= 3; i++ {
+		tbl.AddRow([]interface{}{i})
+		if tbl.BytesWritten() != int64(buf.Len()) {
+			t.Errorf("unexpected number of bytes: %d, expect: %d", tbl.BytesWritten(), buf.Len())
+		}
+	}
+	tbl.Flush()
+	if tbl.BytesWritten() != int64(buf.Len()) {
+		t.Errorf("unexpected number of bytes: %d, expect: %d", tbl.BytesWritten(), buf.Len())
+	}
+	n := tbl.BytesWritten()
+
+	// counted across tables
+	tbl.NextTable()
+	tbl.AddRow([]interface{}{4})
+	tbl.Flush()
+	if tbl.BytesWritten() != int64(buf.Len()) || tbl.BytesWritten() <= n {
+		t.Errorf("unexpected number of bytes: %d, expect: %d", tbl.BytesWritten(), buf.Len())
+	}
+
+	// short writes, "id\n1 \n2 \n" is written by the second AddRow()
+	tbl = New()
+	tbl.Writer(shortWriter{}, 1)
+	tbl.Header([]string{"id"})
+	tbl.AddRow([]interface{}{1})
+	tbl.AddRow([]interface{}{2})
+	tbl.AddRow([]interface{}{3})
+	tbl.Flush()
+	if tbl.BytesWritten() != 4 {
+		t.Errorf("unexpected number of bytes: %d, expect: %d", tbl.BytesWritten(), 4)
+	}
+}
+
+// fakeTerminal is a writer with a file descriptor, treated as a terminal in tests.
+type fakeTerminal struct {
+	bytes.Buffer
+}
+
+func (w *fakeTerminal) Fd() uintptr { return 1024 }
+
+func TestStyleAuto(t *testing.T) {
+	_isTerminal := isTerminal
+	defer func() { isTerminal = _isTerminal }()
+	isTerminal = func(fd int) bool { return fd == 1024 }
+
+	// a terminal
+	tty := &fakeTerminal{}
+	tbl := New().StyleAuto(StyleGrid, StylePlain)
+	tbl.Writer(tty, 1)
+	tbl.Header([]string{"id"})
+	tbl.AddRow([]interface{}{1})
+	tbl.Flush()
+	if tty.String() != "+----+\n| id |\n+====+\n| 1  |\n+----+\n" {
+		t.Errorf("unexpected output:\n%s", tty.String())
+	}
+
+	// a pipe
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tbl = New()
+	tbl.Writer(w, 1)
+	tbl.StyleAuto(StyleGrid, StylePlain) // after setting the writer
+	tbl.Header([]string{"id"})
+	tbl.AddRow([]interface{}{1})
+	tbl.Flush()
+	w.Close()
+	data, _ := io.ReadAll(r)
+	r.Close()
+	if string(data) != "id\n1 \n" {
+		t.Errorf("unexpected output:\n%s", data)
+	}
+
+	// overridden by Style()
+	tty.Reset()
+	tbl = New().StyleAuto(StyleGrid, StylePlain).Style(StyleSimple)
+	tbl.Writer(tty, 1)
+	tbl.Header([]string{"id"})
+	tbl.AddRow([]interface{}{1})
+	tbl.Flush()
+	if tty.String() != "----\n id \n----\n 1  \n----\n" {
+		t.Errorf("unexpected output:\n%q", tty.String())
+	}
+
+	// no writer
+	tbl = New().StyleAuto(StyleGrid, StylePlain)
+	tbl.AddRow([]interface{}{1})
+	if out := string(tbl.Render(nil)); out != "+---+\n| 1 |\n+---+\n" {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+}
+
+func TestWidthsBeforeDump(t *testing.T) {
+	var buf bytes.Buffer
+	tbl := New()
+	tbl.Writer(&buf, 2)
+	tbl.Header([]string{"id", "text"})
+	tbl.AddRow([]interface{}{1, "abcdefgh"})
+	tbl.MaxWidth(4) // applied as the buffer is not dumped
+	tbl.AddRow([]interface{}{2, "abc"})
+	tbl.AddRow([]interface{}{3, "ab"}) // dumping
+	if tbl.Err() != nil {
+		t.Errorf("unexpected error: %s", tbl.Err())
+	}
+
+	tbl.MaxWidth(10).MinWidth(5) // too late
+	if !errors.Is(tbl.Err(), ErrWidthsFrozen) {
+		t.Errorf("expected ErrWidthsFrozen, got %v", tbl.Err())
+	}
+	tbl.AddRow([]interface{}{4, "abcdefgh"})
+	tbl.Flush()
+	expect := `id   text
+1    abcd
+     efgh
+2    abc 
+3    ab  
+4    abcd
+     efgh
+`
+	if buf.String() != expect {
+		t.Errorf("unexpected output:\n%s", buf.String())
+	}
+}
+
+func TestStreamingReleaseBuffer(t *testing.T) {
+	n := 10000
+	tbl := New()
+	tbl.Writer(io.Discard, uint(n))
+	tbl.Header([]string{"id", "name"})
+	for i := 1; i <= n; i++ {
+		tbl.AddRow([]interface{}{i, "apple"})
+	}
+	if len(tbl.rows) != n {
+		t.Errorf("expected %d buffered rows, got %d", n, len(tbl.rows))
+	}
+	tbl.AddSeparator()
+	tbl.AddRow([]interface{}{n + 1, "banana"}) // dumping
+	runtime.GC()
+	if len(tbl.rows) != 0 || tbl.markers != nil {
+		t.Errorf("the buffered rows should be released, %d rows left", len(tbl.rows))
+	}
+	if tbl.NumRows() != n+1 {
+		t.Errorf("expected %d rows, got %d", n+1, tbl.NumRows())
+	}
+
+	// the output is not affected
+	var buf bytes.Buffer
+	tbl = New().Style(StyleGrid)
+	tbl.Writer(&buf, 2)
+	tbl.Header([]string{"id", "name"})
+	for i := 1; i <= 4; i++ {
+		tbl.AddRow([]interface{}{i, "apple"})
+	}
+	tbl.Checkpoint()
+	tbl.AddRow([]interface{}{5, "banana"})
+	tbl.Flush()
+	expect := `+----+-------+
+| id | name  |
++====+=======+
+| 1  | apple |
++----+-------+
+| 2  | apple |
++----+-------+
+| 3  | apple |
++----+-------+
+| 4  | apple |
++----+-------+
++----+-------+
+| id | name  |
++====+=======+
+| 5  | banan |
+|    | a     |
++----+-------+
+`
+	if buf.String() != expect {
+		t.Errorf("unexpected output:\n%s", buf.String())
+	}
+}
@@ -0,0 +1,142 @@
+// Copyright © 2023-2024 Wei Shen <shenwei356@gmail.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package stable
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+)
+
+// teeWriter is an additional writer with its own style, see AddWriter().
+type teeWriter struct {
+	w     io.Writer
+	style *TableStyle
+}
+
+// ErrAddWriterWithoutWriter means AddWriter() is called before setting the writer.
+var ErrAddWriterWithoutWriter = fmt.Errorf("stable: calling AddWriter is only allowed after setting the writer")
+
+// AddWriter adds another writer in streaming mode, which receives the same table
+// in its own style, e.g., a grid table for the terminal and a plain one for a log file.
+// Rows are converted only once, and formatted once for each writer,
+// with the column widths determined for the style of the primary writer.
+// If style is nil, the style of the table is used.
+// Flush() finalizes all writers. The first error of writing is sticky for all writers,
+// and errors of the additional writers are like "stable: writing data row to writer 2: ...",
+// where the primary writer is the first one.
+// Only the bytes written to the primary writer are counted by BytesWritten().
+// It should be called after Writer() and before any data is written.
+func (t *Table) AddWriter(w io.Writer, style *TableStyle) error {
+	if !t.hasWriter {
+		return ErrAddWriterWithoutWriter
+	}
+	if t.bufRowsDumped || t.flushed {
+		return fmt.Errorf("%w: %d rows written", ErrWriterInUse, len(t.rows)+t.nStreamed)
+	}
+	t.teeWriters = append(t.teeWriters, teeWriter{w: w, style: style})
+	return nil
+}
+
+// rowState is the state of writing data rows, which is restored before
+// formatting the same data for another writer.
+type rowState struct {
+	rowNumber      int
+	prevRow        []string
+	afterSeparator bool
+	nOmitted       int
+}
+
+func (t *Table) saveRowState() rowState {
+	return rowState{
+		rowNumber:      t.rowNumber,
+		prevRow:        t.prevRow,
+		afterSeparator: t.afterSeparator,
+		nOmitted:       t.nOmitted,
+	}
+}
+
+func (t *Table) restoreRowState(s rowState) {
+	t.rowNumber = s.rowNumber
+	t.prevRow = s.prevRow
+	t.afterSeparator = s.afterSeparator
+	t.nOmitted = s.nOmitted
+}
+
+// emit formats data with f for the writer and each additional writer with its own style,
+// starting from the same state of writing rows, and writes it.
+// what describes the data for the error message.
+func (t *Table) emit(what string, style *TableStyle, f func(buf *bytes.Buffer, style *TableStyle)) {
+	state := t.saveRowState()
+
+	buf := t.buf
+	buf.Reset()
+
+	f(&buf, style)
+	t.write(what, t.prefixLines(buf.Bytes()))
+	buf.Reset()
+
+	for i, tw := range t.teeWriters {
+		t.restoreRowState(state)
+		f(&buf, tw.styleOr(style))
+		t.writeTee(i, what, t.prefixLines(buf.Bytes()))
+		buf.Reset()
+	}
+}
+
+// styleOr returns the style of the writer, or the given one if it's not set.
+func (tw teeWriter) styleOr(style *TableStyle) *TableStyle {
+	if tw.style == nil {
+		return style
+	}
+	return tw.style
+}
+
+// writeTee writes data to the i-th additional writer, what describes the data for the error message.
+// The first error is recorded like write().
+func (t *Table) writeTee(i int, what string, data []byte) {
+	if t.writeErr != nil {
+		return
+	}
+	n, err := t.teeWriters[i].w.Write(data)
+	if err == nil && n < len(data) {
+		err = io.ErrShortWrite
+	}
+	if err != nil {
+		t.writeErr = fmt.Errorf("stable: writing %s to writer %d: %w", what, i+2, err)
+		if t.err == nil {
+			t.err = t.writeErr
+		}
+	}
+}
+
+// flushTeeWriters flushes the additional writers if they are buffered.
+func (t *Table) flushTeeWriters() {
+	for i, tw := range t.teeWriters {
+		if w, ok := tw.w.(interface{ Flush() error }); ok && t.writeErr == nil {
+			if err := w.Flush(); err != nil {
+				t.writeErr = fmt.Errorf("stable: flushing writer %d: %w", i+2, err)
+				if t.err == nil {
+					t.err = t.writeErr
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,77 @@
+// Copyright © 2023-2024 Wei Shen <shenwei356@gmail.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package stable
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestAddWriter(t *testing.T) {
+	addRows := func(tbl *Table) {
+		tbl.Header([]string{"id", "name"})
+		tbl.AddRow([]interface{}{1, "apple"})
+		tbl.AddRow([]interface{}{2, "banana"})
+		tbl.AddSeparator()
+		tbl.AddRow([]interface{}{3, "cherry"})
+		tbl.AddRow([]interface{}{4, "date"})
+	}
+	render := func(style *TableStyle) string {
+		tbl := New()
+		addRows(tbl)
+		return string(tbl.Render(style))
+	}
+
+	for _, bufRows := range []uint{2, 10, 0} {
+		var grid, plain bytes.Buffer
+		tbl := New().Style(StyleGrid)
+		tbl.Writer(&grid, bufRows)
+		if err := tbl.AddWriter(&plain, StylePlain); err != nil {
+			t.Fatal(err)
+		}
+		addRows(tbl)
+		if err := tbl.FlushE(); err != nil {
+			t.Fatal(err)
+		}
+		if expect := render(StyleGrid); grid.String() != expect {
+			t.Errorf("bufRows %d: unexpected output of the grid style:\n%s", bufRows, grid.String())
+		}
+		if expect := render(StylePlain); plain.String() != expect {
+			t.Errorf("bufRows %d: unexpected output of the plain style:\n%s", bufRows, plain.String())
+		}
+	}
+
+	// errors
+	tbl := New()
+	if err := tbl.AddWriter(&bytes.Buffer{}, nil); err != ErrAddWriterWithoutWriter {
+		t.Errorf("expected ErrAddWriterWithoutWriter, got %v", err)
+	}
+
+	tbl.Writer(&bytes.Buffer{}, 1)
+	tbl.AddWriter(failedWriter{}, nil)
+	addRows(tbl)
+	if err := tbl.FlushE(); err == nil || err.Error() != "stable: writing header and buffered rows to writer 2: disk full" {
+		t.Errorf("expected an error of writing, got %v", err)
+	}
+	if err := tbl.AddWriter(&bytes.Buffer{}, nil); !errors.Is(err, ErrWriterInUse) {
+		t.Errorf("expected ErrWriterInUse, got %v", err)
+	}
+}
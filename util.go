@@ -20,20 +20,150 @@
 package stable
 
 import (
+	"encoding"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/dustin/go-humanize"
 )
 
+// errors of converting values, which are wrapped in ConversionError.
+var (
+	errUnsupportedType = errors.New("unsupported type")
+	errNilValue        = errors.New("nil values are not allowed")
+	errNestedSlice     = errors.New("nested slices are not supported")
+	errNestedMap       = errors.New("nested maps are not supported")
+)
+
+// ConversionError means a value of a row can not be converted to a string.
+// Use errors.As() to retrieve it from the error returned by AddRow() and others.
+type ConversionError struct {
+	Column int          // 0-based index of the column
+	Header string       // header of the column, empty if there's no header
+	Value  interface{}  // the value
+	Type   reflect.Type // type of the value, nil for a nil value
+	Err    error        // the cause
+}
+
+func (e *ConversionError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "stable: cannot convert value of type %v in column %d", e.Type, e.Column+1)
+	if e.Header != "" {
+		fmt.Fprintf(&b, " (%q)", e.Header)
+	}
+	if e.Err != errUnsupportedType {
+		fmt.Fprintf(&b, ": %s", e.Err)
+	}
+	return b.String()
+}
+
+func (e *ConversionError) Unwrap() error {
+	return e.Err
+}
+
+// conversionError wraps an error of converting the value of a column.
+func (t *Table) conversionError(col int, v interface{}, err error) error {
+	e := &ConversionError{Column: col, Value: v, Type: reflect.TypeOf(v), Err: err}
+	if t.hasHeader {
+		e.Header = t.columns[col].Header
+	}
+	return e
+}
+
+// convertCell converts the value of a column, with the column's ConvertFunc applied first.
+// Errors are wrapped in ConversionError.
+func (t *Table) convertCell(v interface{}, col int) (string, error) {
+	raw := v
+	if f := t.columns[col].ConvertFunc; f != nil {
+		var err error
+		if v, err = f(v); err != nil {
+			return "", t.conversionError(col, raw, fmt.Errorf("ConvertFunc: %w", err))
+		}
+	}
+	s, err := t.convertToString(v, col)
+	if err != nil {
+		return "", t.conversionError(col, v, err)
+	}
+	return s, nil
+}
+
 // from https://github.com/tatsushid/go-prettytable, with little changes
-func (t *Table) convertToString(v interface{}, addComma bool) (string, error) {
+// col is the 0-based index of the column, for column-specific options.
+func (t *Table) convertToString(v interface{}, col int) (string, error) {
+	if v == nil || isNilPointer(v) {
+		if t.strictNil {
+			return "", errNilValue
+		}
+		return "", nil
+	}
+
+	addComma := (t.humanizeNumbers || t.columns[col].HumanizeNumbers) && !t.columns[col].NoHumanizeNumbers
+
+	// types also implementing fmt.Stringer, errors, and complex numbers
+	switch vv := v.(type) {
+	case time.Duration:
+		return t.formatDuration(vv, col), nil
+	case time.Time:
+		return t.formatTime(vv, col), nil
+	case *big.Int:
+		if addComma {
+			return humanize.BigComma(new(big.Int).Set(vv)), nil // it modifies the argument
+		}
+		return vv.String(), nil
+	case *big.Float:
+		s := vv.String()
+		if format, prec, ok := t.floatFormatOf(col); ok {
+			s = vv.Text(format, prec)
+		}
+		if addComma {
+			return commaDecimal(s), nil
+		}
+		return s, nil
+	case *big.Rat:
+		var s string
+		if t.ratFraction {
+			s = vv.RatString()
+		} else if _, prec, ok := t.floatFormatOf(col); ok && prec >= 0 {
+			s = vv.FloatString(prec)
+		} else {
+			f, _ := vv.Float64()
+			s = strconv.FormatFloat(f, 'g', -1, 64)
+		}
+		if addComma {
+			return commaDecimal(s), nil
+		}
+		return s, nil
+	case error: // it wins over fmt.Stringer for types implementing both, like in package fmt
+		return callString(vv.Error)
+	case json.Number:
+		if i, err := vv.Int64(); err == nil {
+			return t.convertToString(i, col)
+		}
+		if i, ok := new(big.Int).SetString(string(vv), 10); ok { // a large integer
+			return t.convertToString(i, col)
+		}
+		if f, err := vv.Float64(); err == nil {
+			return t.convertToString(f, col)
+		}
+		return t.convertCharacters(string(vv)), nil
+	case complex64: // never humanized
+		return t.formatComplex(complex128(vv), 64, col), nil
+	case complex128:
+		return t.formatComplex(vv, 128, col), nil
+	}
+
 	if addComma {
 		switch vv := v.(type) {
 		case fmt.Stringer:
-			return vv.String(), nil
+			return callString(vv.String)
 		case int:
 			return humanize.Comma(int64(vv)), nil
 		case int8:
@@ -45,7 +175,7 @@ func (t *Table) convertToString(v interface{}, addComma bool) (string, error) {
 		case int64:
 			return humanize.Comma(vv), nil
 		case uint:
-			return humanize.Comma(int64(vv)), nil
+			return commaUint(uint64(vv)), nil
 		case uint8:
 			return humanize.Comma(int64(vv)), nil
 		case uint16:
@@ -53,10 +183,16 @@ func (t *Table) convertToString(v interface{}, addComma bool) (string, error) {
 		case uint32:
 			return humanize.Comma(int64(vv)), nil
 		case uint64:
-			return humanize.Comma(int64(vv)), nil
+			return commaUint(vv), nil
 		case float32:
+			if format, prec, ok := t.floatFormatOf(col); ok {
+				return commaDecimal(strconv.FormatFloat(float64(vv), format, prec, 32)), nil
+			}
 			return humanize.Commaf(float64(vv)), nil
 		case float64:
+			if format, prec, ok := t.floatFormatOf(col); ok {
+				return commaDecimal(strconv.FormatFloat(vv, format, prec, 64)), nil
+			}
 			return humanize.Commaf(float64(vv)), nil
 		case bool:
 			return strconv.FormatBool(vv), nil
@@ -67,13 +203,13 @@ func (t *Table) convertToString(v interface{}, addComma bool) (string, error) {
 		case []rune:
 			return t.convertCharacters(string(vv)), nil
 		default:
-			return "", errors.New("can't convert the value")
+			return t.convertOther(v, col)
 		}
 	}
 
 	switch vv := v.(type) {
 	case fmt.Stringer:
-		return vv.String(), nil
+		return callString(vv.String)
 	case int:
 		return strconv.FormatInt(int64(vv), 10), nil
 	case int8:
@@ -95,8 +231,14 @@ func (t *Table) convertToString(v interface{}, addComma bool) (string, error) {
 	case uint64:
 		return strconv.FormatUint(vv, 10), nil
 	case float32:
+		if format, prec, ok := t.floatFormatOf(col); ok {
+			return strconv.FormatFloat(float64(vv), format, prec, 32), nil
+		}
 		return strconv.FormatFloat(float64(vv), 'g', -1, 32), nil
 	case float64:
+		if format, prec, ok := t.floatFormatOf(col); ok {
+			return strconv.FormatFloat(vv, format, prec, 64), nil
+		}
 		return strconv.FormatFloat(vv, 'g', -1, 64), nil
 	case bool:
 		return strconv.FormatBool(vv), nil
@@ -107,11 +249,315 @@ func (t *Table) convertToString(v interface{}, addComma bool) (string, error) {
 	case []rune:
 		return t.convertCharacters(string(vv)), nil
 	default:
-		return "", errors.New("can't convert the value")
+		return t.convertOther(v, col)
+	}
+}
+
+// convertOther converts values of other types, i.e., encoding.TextMarshaler,
+// slices and arrays, whose elements are converted individually and joined with the delimiter,
+// and maps with string keys, which are rendered like "k1=v1, k2=v2" with sorted keys.
+// fmt.Stringer and built-in types are checked before, so they win over encoding.TextMarshaler.
+// Values of unsupported types are formatted with "%v" in lenient mode.
+func (t *Table) convertOther(v interface{}, col int) (string, error) {
+	if m, ok := v.(encoding.TextMarshaler); ok {
+		text, err := m.MarshalText()
+		if err != nil {
+			return "", fmt.Errorf("marshaling text: %w", err)
+		}
+		return t.convertCharacters(string(text)), nil
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		n := rv.Len()
+		if n == 0 {
+			return "", nil
+		}
+		sep := t.sliceDelimiterOf(col)
+		var b strings.Builder
+		for i := 0; i < n; i++ {
+			e := rv.Index(i).Interface()
+			if k := reflect.ValueOf(e).Kind(); k == reflect.Slice || k == reflect.Array {
+				switch e.(type) {
+				case []byte, []rune: // strings
+				default:
+					if !t.lenient {
+						return "", errNestedSlice
+					}
+				}
+			}
+			s, err := t.convertToString(e, col)
+			if err != nil {
+				return "", err
+			}
+			if i > 0 {
+				b.WriteString(sep)
+			}
+			b.WriteString(s)
+		}
+		return b.String(), nil
+	case reflect.Map:
+		if rv.Type().Key().Kind() != reflect.String {
+			break
+		}
+		keys := rv.MapKeys()
+		sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+		var b strings.Builder
+		for i, k := range keys {
+			e := rv.MapIndex(k).Interface()
+			if reflect.ValueOf(e).Kind() == reflect.Map && !t.lenient {
+				return "", errNestedMap
+			}
+			s, err := t.convertToString(e, col)
+			if err != nil {
+				return "", err
+			}
+			if i > 0 {
+				b.WriteString(t.sliceDelimiterOf(col))
+			}
+			b.WriteString(t.convertCharacters(k.String()))
+			b.WriteByte('=')
+			b.WriteString(s)
+		}
+		return b.String(), nil
+	}
+
+	if t.lenient {
+		return t.convertCharacters(fmt.Sprintf("%v", v)), nil
+	}
+	return "", errUnsupportedType
+}
+
+// sliceDelimiterOf returns the delimiter for joining elements of slices, arrays, and maps.
+func (t *Table) sliceDelimiterOf(col int) string {
+	if sep := t.columns[col].SliceDelimiter; sep != "" {
+		return sep
+	}
+	return t.sliceDelimiter
+}
+
+// floatFormatOf returns the format and precision of floats for a column,
+// the column options override the global ones set by FloatFormat().
+// ok is false if neither is set.
+func (t *Table) floatFormatOf(col int) (format byte, prec int, ok bool) {
+	c := t.columns[col]
+	format, prec = t.floatFmt, t.floatPrec
+	if c.FloatFormat != 0 {
+		format = c.FloatFormat
 	}
+	if c.Precision > 0 || c.PrecisionSet {
+		prec = c.Precision
+	} else if !t.floatFmtSet {
+		prec = -1
+	}
+	if format == 0 {
+		if prec < 0 {
+			return 0, 0, false
+		}
+		format = 'f'
+	}
+	return format, prec, true
+}
+
+// formatComplex formats a complex number as "a+bi" with the format and precision of floats.
+func (t *Table) formatComplex(c complex128, bitSize int, col int) string {
+	if t.omitZeroImag && imag(c) == 0 {
+		if format, prec, ok := t.floatFormatOf(col); ok {
+			return strconv.FormatFloat(real(c), format, prec, bitSize/2)
+		}
+		return strconv.FormatFloat(real(c), 'g', -1, bitSize/2)
+	}
+	var s string
+	if format, prec, ok := t.floatFormatOf(col); ok {
+		s = strconv.FormatComplex(c, format, prec, bitSize)
+	} else {
+		s = strconv.FormatComplex(c, 'g', -1, bitSize)
+	}
+	return s[1 : len(s)-1] // removing the parentheses
+}
+
+// formatDuration formats a time.Duration value with the column or global format.
+func (t *Table) formatDuration(d time.Duration, col int) string {
+	if f := t.columns[col].DurationFormat; f != nil {
+		return f(d)
+	}
+	if t.durationFormat != nil {
+		return t.durationFormat(d)
+	}
+	return d.String()
+}
+
+// formatTime formats a time.Time value with the column or global layout.
+// The monotonic clock reading is stripped, and a zero time is empty.
+func (t *Table) formatTime(tm time.Time, col int) string {
+	if tm.IsZero() {
+		return ""
+	}
+	layout := t.columns[col].TimeLayout
+	if layout == "" {
+		layout = t.timeLayout
+	}
+	if layout == "" {
+		layout = time.RFC3339
+	}
+	return tm.Round(0).Format(layout)
+}
+
+// DurationHMS formats a duration as "HH:MM:SS", rounded to seconds, e.g., "01:02:03" and "-00:00:05".
+// Hours are not limited to two digits. It can be used in DurationFormat.
+func DurationHMS(d time.Duration) string {
+	d = d.Round(time.Second)
+	sign := ""
+	if d < 0 {
+		sign = "-"
+		d = -d
+	}
+	h := d / time.Hour
+	d -= h * time.Hour
+	m := d / time.Minute
+	d -= m * time.Minute
+	return fmt.Sprintf("%s%02d:%02d:%02d", sign, h, m, d/time.Second)
+}
+
+// callString calls the method String() or Error() of a value.
+// Typed nil pointers are checked before, while a panic of the method,
+// e.g., dereferencing a nil field, is returned as an error.
+func callString(f func() string) (s string, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return f(), nil
+}
+
+// isNilPointer tells whether the value is a typed nil pointer, e.g., (*int)(nil).
+func isNilPointer(v interface{}) bool {
+	rv := reflect.ValueOf(v)
+	return rv.Kind() == reflect.Ptr && rv.IsNil()
+}
+
+// commaUint adds commas to an unsigned integer, values above math.MaxInt64
+// would overflow in humanize.Comma.
+func commaUint(v uint64) string {
+	if v <= math.MaxInt64 {
+		return humanize.Comma(int64(v))
+	}
+	return humanize.BigComma(new(big.Int).SetUint64(v))
+}
+
+// commaDecimal adds commas to the integer part of a formatted number, e.g., "-1234.50" -> "-1,234.50".
+func commaDecimal(s string) string {
+	start := 0
+	if start < len(s) && (s[start] == '-' || s[start] == '+') {
+		start++
+	}
+	end := start
+	for end < len(s) && s[end] >= '0' && s[end] <= '9' {
+		end++
+	}
+	if end-start <= 3 {
+		return s
+	}
+	var b strings.Builder
+	b.Grow(len(s) + (end-start-1)/3)
+	b.WriteString(s[:start])
+	for i := start; i < end; i++ {
+		if i > start && (end-i)%3 == 0 {
+			b.WriteByte(',')
+		}
+		b.WriteByte(s[i])
+	}
+	b.WriteString(s[end:])
+	return b.String()
+}
+
+// ParseNumeric parses a cell as a number, e.g., for sorting, summarizing, or formatting cells.
+// Leading and trailing spaces are ignored, and it accepts an optional sign, grouping commas
+// added by HumanizeNumbers (e.g., "1,234,567.5"), a decimal point, scientific notation (e.g., "1.5e-3"),
+// and a trailing percent sign (e.g., "12.5%" -> 12.5).
+// Other text, like IP addresses, version strings, "Inf", "NaN", and misplaced commas, is not a number.
+func ParseNumeric(s string) (float64, bool) {
+	return parseNumeric(s)
+}
+
+// parseNumeric is the internal version of ParseNumeric.
+func parseNumeric(s string) (float64, bool) {
+	s = strings.TrimSpace(s)
+	s = strings.TrimSuffix(s, "%")
+	n := len(s)
+	i := 0
+	if i < n && (s[i] == '+' || s[i] == '-') {
+		i++
+	}
+
+	// the integer part, commas are only allowed between groups of three digits
+	var digits, group int
+	var hasComma bool
+	start := i
+	for ; i < n; i++ {
+		if c := s[i]; c >= '0' && c <= '9' {
+			digits++
+			group++
+		} else if c == ',' {
+			if group == 0 || (hasComma && group != 3) || (!hasComma && group > 3) {
+				return 0, false
+			}
+			hasComma = true
+			group = 0
+		} else {
+			break
+		}
+	}
+	if hasComma && group != 3 {
+		return 0, false
+	}
+	intEnd := i
+
+	// the fraction part
+	if i < n && s[i] == '.' {
+		i++
+		for ; i < n && s[i] >= '0' && s[i] <= '9'; i++ {
+			digits++
+		}
+	}
+	if digits == 0 {
+		return 0, false
+	}
+
+	// the exponent
+	if i < n && (s[i] == 'e' || s[i] == 'E') {
+		i++
+		if i < n && (s[i] == '+' || s[i] == '-') {
+			i++
+		}
+		expStart := i
+		for ; i < n && s[i] >= '0' && s[i] <= '9'; i++ {
+		}
+		if i == expStart {
+			return 0, false
+		}
+	}
+	if i != n {
+		return 0, false
+	}
+
+	if hasComma {
+		s = s[:start] + strings.ReplaceAll(s[start:intEnd], ",", "") + s[intEnd:]
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil { // out of range
+		return 0, false
+	}
+	return f, true
 }
 
 func (t *Table) convertCharacters(v string) string {
+	if !t.keepCR && strings.IndexByte(v, '\r') >= 0 { // line endings of Windows and classic Mac OS
+		v = strings.ReplaceAll(v, "\r\n", "\n")
+		v = strings.ReplaceAll(v, "\r", "\n")
+	}
 	if len(t.convTable) > 0 {
 		for from, to := range t.convTable {
 			v = strings.ReplaceAll(v, from, to)
@@ -0,0 +1,626 @@
+// Copyright © 2023-2024 Wei Shen <shenwei356@gmail.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package stable
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"net"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestConvertLargeUnsigned(t *testing.T) {
+	tests := []struct {
+		v        interface{}
+		addComma bool
+		expect   string
+	}{
+		{uint64(math.MaxUint64), false, "18446744073709551615"},
+		{uint64(math.MaxUint64), true, "18,446,744,073,709,551,615"},
+		{uint64(math.MaxInt64 + 1), false, "9223372036854775808"},
+		{uint64(math.MaxInt64 + 1), true, "9,223,372,036,854,775,808"},
+		{uint64(math.MaxInt64), true, "9,223,372,036,854,775,807"},
+		{uint(math.MaxUint), true, "18,446,744,073,709,551,615"},
+	}
+	if math.MaxUint == math.MaxUint32 { // 32-bit platforms
+		tests[len(tests)-1].expect = "4,294,967,295"
+	}
+	for _, test := range tests {
+		tbl := New()
+		if test.addComma {
+			tbl.HumanizeNumbers()
+		}
+		tbl.Header([]string{"a"})
+		s, err := tbl.convertToString(test.v, 0)
+		if err != nil {
+			t.Errorf("%v: %s", test.v, err)
+		} else if s != test.expect {
+			t.Errorf("%v (humanize: %v): expected %s, got %s", test.v, test.addComma, test.expect, s)
+		}
+	}
+}
+
+type nilStringer struct{ s string }
+
+func (s *nilStringer) String() string { return s.s }
+
+func TestConvertNil(t *testing.T) {
+	var p *int
+	var s *nilStringer
+	var stringer fmt.Stringer = s
+
+	tbl := New()
+	tbl.Header([]string{"id", "a", "b", "c"})
+	if err := tbl.AddRow([]interface{}{1, nil, p, stringer}); err != nil {
+		t.Fatal(err)
+	}
+	if err := tbl.AddRow([]interface{}{2, "x", 3, &nilStringer{"y"}}); err != nil {
+		t.Fatal(err)
+	}
+	expect := "id   a   b   c\n" +
+		"1             \n" +
+		"2    x   3   y\n"
+	if out := string(tbl.Render(StylePlain)); out != expect {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+
+	// strict mode
+	tbl = New().StrictNil()
+	tbl.Header([]string{"id", "a"})
+	for _, v := range []interface{}{nil, p, stringer} {
+		if err := tbl.AddRow([]interface{}{1, v}); err == nil {
+			t.Errorf("%#v: expected an error in strict mode", v)
+		}
+	}
+	if tbl.NumRows() != 0 {
+		t.Errorf("expected no rows, got %d", tbl.NumRows())
+	}
+}
+
+func TestConvertDuration(t *testing.T) {
+	durations := []time.Duration{
+		1500 * time.Nanosecond,
+		2*time.Hour + 3*time.Minute + 4500*time.Millisecond,
+		-5 * time.Second,
+	}
+
+	tbl := New().HumanizeNumbers()
+	tbl.HeaderWithFormat([]Column{{Header: "id"}, {Header: "time", Align: AlignRight}})
+	for i, d := range durations {
+		tbl.AddRow([]interface{}{i + 1, d})
+	}
+	expect := `id       time
+1       1.5µs
+2    2h3m4.5s
+3         -5s
+`
+	if out := string(tbl.Render(StylePlain)); out != expect {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+
+	// the global format and the column one
+	tbl = New().DurationFormat(DurationHMS)
+	tbl.HeaderWithFormat([]Column{
+		{Header: "hms", Align: AlignRight},
+		{Header: "ms", Align: AlignRight, DurationFormat: func(d time.Duration) string {
+			return d.Round(time.Millisecond).String()
+		}},
+	})
+	for _, d := range durations {
+		tbl.AddRow([]interface{}{d, d})
+	}
+	expect = `      hms         ms
+ 00:00:00         0s
+ 02:03:05   2h3m4.5s
+-00:00:05        -5s
+`
+	if out := string(tbl.Render(StylePlain)); out != expect {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+}
+
+func TestConvertTime(t *testing.T) {
+	now := time.Now() // with a monotonic clock reading
+	if !strings.Contains(now.String(), "m=") {
+		t.Skip("no monotonic clock reading")
+	}
+	wall := time.Date(now.Year(), now.Month(), now.Day(), now.Hour(), now.Minute(), now.Second(),
+		now.Nanosecond(), now.Location())
+
+	tbl := New()
+	tbl.HeaderWithFormat([]Column{{Header: "default"}, {Header: "date", TimeLayout: "2006-01-02"}})
+	tbl.AddRow([]interface{}{now, now})
+	tbl.AddRow([]interface{}{wall, wall})
+	tbl.AddRow([]interface{}{time.Time{}, time.Time{}})
+	rows := tbl.Rows()
+	for i := 0; i < 2; i++ {
+		if rows[0][i] != rows[1][i] {
+			t.Errorf("the monotonic clock reading should be stripped: %q vs %q", rows[0][i], rows[1][i])
+		}
+	}
+	if rows[0][0] != now.Format(time.RFC3339) || rows[0][1] != now.Format("2006-01-02") {
+		t.Errorf("unexpected cells: %q", rows[0])
+	}
+	if rows[2][0] != "" || rows[2][1] != "" {
+		t.Errorf("zero times should be empty: %q", rows[2])
+	}
+
+	// the global layout
+	tbl = New().TimeLayout(time.Kitchen)
+	tbl.Header([]string{"time"})
+	tbl.AddRow([]interface{}{time.Date(2024, 12, 5, 15, 4, 0, 0, time.UTC)})
+	if cell := tbl.Rows()[0][0]; cell != "3:04PM" {
+		t.Errorf("unexpected cell: %q", cell)
+	}
+}
+
+func TestConvertBig(t *testing.T) {
+	i, _ := new(big.Int).SetString("1234567890123456789012345678901234567890", 10)
+	f := new(big.Float).SetPrec(200).Quo(big.NewFloat(12345), big.NewFloat(7))
+	r := big.NewRat(1, 3)
+	var nilInt *big.Int
+
+	tbl := New().HumanizeNumbers()
+	tbl.HeaderWithFormat([]Column{{Header: "int"}, {Header: "float", Precision: 4},
+		{Header: "rat", Precision: 2}, {Header: "nil"}})
+	tbl.AddRow([]interface{}{i, f, r, nilInt})
+	expect := []string{"1,234,567,890,123,456,789,012,345,678,901,234,567,890", "1,763.5714", "0.33", ""}
+	if row := tbl.Rows()[0]; !reflect.DeepEqual(row, expect) {
+		t.Errorf("expected %q, got %q", expect, row)
+	}
+
+	// not humanized, with default formats
+	tbl = New()
+	tbl.Header([]string{"int", "float", "rat"})
+	tbl.AddRow([]interface{}{i, f, r})
+	expect = []string{"1234567890123456789012345678901234567890", "1763.571429", "0.3333333333333333"}
+	if row := tbl.Rows()[0]; !reflect.DeepEqual(row, expect) {
+		t.Errorf("expected %q, got %q", expect, row)
+	}
+
+	tbl = New().RatFraction()
+	tbl.Header([]string{"rat"})
+	tbl.AddRow([]interface{}{big.NewRat(-6, 4)})
+	if cell := tbl.Rows()[0][0]; cell != "-3/2" {
+		t.Errorf("unexpected cell: %q", cell)
+	}
+}
+
+type stringerError struct{}
+
+func (e stringerError) Error() string  { return "error message" }
+func (e stringerError) String() string { return "string" }
+
+func TestConvertError(t *testing.T) {
+	var nilErr error
+	wrapped := fmt.Errorf("reading file: %w", os.ErrNotExist)
+
+	tbl := New()
+	tbl.Header([]string{"id", "error"})
+	for i, err := range []error{wrapped, nilErr, stringerError{}} {
+		if err := tbl.AddRow([]interface{}{i + 1, err}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	expect := []string{"reading file: file does not exist", "", "error message"}
+	for i, row := range tbl.Rows() {
+		if row[1] != expect[i] {
+			t.Errorf("row %d: expected %q, got %q", i+1, expect[i], row[1])
+		}
+	}
+}
+
+type person struct {
+	name *string
+}
+
+func (p *person) String() string { return *p.name }
+
+func TestConvertPanickingStringer(t *testing.T) {
+	name := "Wei"
+	var nilPerson *person
+
+	tbl := New()
+	tbl.Header([]string{"id", "name"})
+	if err := tbl.AddRow([]interface{}{1, &person{&name}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := tbl.AddRow([]interface{}{2, nilPerson}); err != nil { // a typed nil
+		t.Fatal(err)
+	}
+	err := tbl.AddRow([]interface{}{3, &person{}}) // a nil field
+	if err == nil || !strings.HasPrefix(err.Error(),
+		`stable: cannot convert value of type *stable.person in column 2 ("name"): panic: `) {
+		t.Errorf("expected an error of the panic, got %v", err)
+	}
+	if rows := tbl.Rows(); len(rows) != 2 || rows[0][1] != "Wei" || rows[1][1] != "" {
+		t.Errorf("unexpected rows: %q", rows)
+	}
+}
+
+func TestConvertSlice(t *testing.T) {
+	tbl := New().HumanizeNumbers().MaxWidth(12)
+	tbl.HeaderWithFormat([]Column{{Header: "strings"}, {Header: "ints"},
+		{Header: "floats", SliceDelimiter: ";"}})
+	if err := tbl.AddRow([]interface{}{[]string{"a", "b"}, [3]int{1, 2000, 3}, []float64{1.5, 2500.25}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := tbl.AddRow([]interface{}{[]string{}, []int(nil), []interface{}{"x", 1, nil}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := tbl.AddRow([]interface{}{[]string{"apple", "banana", "cherry"}, 1, 2}); err != nil {
+		t.Fatal(err)
+	}
+	expect := `strings        ints          floats      
+a, b           1, 2,000, 3   1.5;2,500.25
+                             x;1;        
+apple,         1             2           
+banana,                                  
+cherry                                   
+`
+	if out := string(tbl.Render(StylePlain)); out != expect {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+
+	// nested slices
+	if err := tbl.AddRow([]interface{}{[][]int{{1}}, 1, 2}); err == nil {
+		t.Errorf("expected an error of nested slices")
+	}
+}
+
+type point struct{ x, y int }
+
+func (p point) MarshalText() ([]byte, error) {
+	if p.x < 0 {
+		return nil, errors.New("negative x")
+	}
+	return []byte(fmt.Sprintf("（%d，%d）", p.x, p.y)), nil
+}
+
+func TestConvertTextMarshaler(t *testing.T) {
+	tbl := New()
+	tbl.Header([]string{"ip", "point"})
+	if err := tbl.AddRow([]interface{}{net.ParseIP("192.168.0.1"), point{1, 2}}); err != nil {
+		t.Fatal(err)
+	}
+	expect := "ip            point   \n" +
+		"192.168.0.1   （1，2）\n"
+	if out := string(tbl.Render(StylePlain)); out != expect {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+
+	err := tbl.AddRow([]interface{}{net.IP(nil), point{-1, 2}})
+	if err == nil || err.Error() != `stable: cannot convert value of type stable.point in column 2 ("point"): marshaling text: negative x` {
+		t.Errorf("expected an error of marshaling, got %v", err)
+	}
+}
+
+func TestLenientConversion(t *testing.T) {
+	values := []interface{}{struct {
+		a int
+		b string
+	}{1, "x"}, map[int]bool{1: true}, make(chan int), [][]int{{1, 2}, {3}}}
+
+	tbl := New()
+	tbl.Header([]string{"value"})
+	for _, v := range values {
+		if err := tbl.AddRow([]interface{}{v}); err == nil {
+			t.Errorf("%T: expected an error", v)
+		}
+	}
+
+	tbl = New().LenientConversion().MaxWidth(10)
+	tbl.Header([]string{"value"})
+	for _, v := range values {
+		if err := tbl.AddRow([]interface{}{v}); err != nil {
+			t.Errorf("%T: %s", v, err)
+		}
+	}
+	rows := tbl.Rows()
+	if rows[0][0] != "{1 x}" || rows[1][0] != "map[1:true]" || !strings.HasPrefix(rows[2][0], "0x") ||
+		rows[3][0] != "1, 2, 3" {
+		t.Errorf("unexpected rows: %q", rows)
+	}
+	for _, line := range strings.Split(strings.TrimSuffix(string(tbl.Render(StylePlain)), "\n"), "\n") {
+		if len(line) > 10 {
+			t.Errorf("the line is not wrapped: %q", line)
+		}
+	}
+}
+
+func TestFloatFormat(t *testing.T) {
+	tbl := New().FloatFormat('f', 2)
+	tbl.HeaderWithFormat([]Column{{Header: "global"}, {Header: "humanized", HumanizeNumbers: true},
+		{Header: "column", FloatFormat: 'e', Precision: 1}, {Header: "float32"}})
+	tbl.AddRow([]interface{}{0.1 + 0.2, 1234567.891, 1234.5, float32(2.5)})
+	tbl.AddRow([]interface{}{-0.000001234, -1234.5, big.NewFloat(0.25), float32(-0.000001234)})
+	expect := [][]string{
+		{"0.30", "1,234,567.89", "1.2e+03", "2.50"},
+		{"-0.00", "-1,234.50", "2.5e-01", "-0.00"},
+	}
+	if rows := tbl.Rows(); !reflect.DeepEqual(rows, expect) {
+		t.Errorf("expected %q, got %q", expect, rows)
+	}
+
+	// precision 0 of a column overrides the global one
+	tbl = New().FloatFormat('f', 2)
+	tbl.HeaderWithFormat([]Column{{Header: "global"}, {Header: "integer", PrecisionSet: true}})
+	tbl.AddRow([]interface{}{2.5, 1234.5})
+	expect = [][]string{{"2.50", "1234"}}
+	if rows := tbl.Rows(); !reflect.DeepEqual(rows, expect) {
+		t.Errorf("expected %q, got %q", expect, rows)
+	}
+
+	// small numbers do not widen the column in the format 'f'
+	width := func(tbl *Table) int {
+		tbl.Header([]string{"a"})
+		tbl.AddRow([]interface{}{-0.000001234})
+		tbl.AddRow([]interface{}{0.5})
+		widths, _ := tbl.ColumnWidths(StylePlain)
+		return widths[0]
+	}
+	if w := width(New().FloatFormat('f', 2)); w != 5 {
+		t.Errorf("unexpected width in the format 'f': %d", w)
+	}
+	if w := width(New()); w != 10 {
+		t.Errorf("unexpected width in the format 'g': %d", w)
+	}
+}
+
+func TestConversionError(t *testing.T) {
+	tbl := New()
+	tbl.Header([]string{"id", "status"})
+	err := tbl.AddRows([][]interface{}{{1, "ok"}, {2, make(chan int)}})
+	var e *ConversionError
+	if !errors.As(err, &e) {
+		t.Fatalf("expected a ConversionError, got %v", err)
+	}
+	if e.Column != 1 || e.Header != "status" || e.Type.String() != "chan int" {
+		t.Errorf("unexpected error: %+v", e)
+	}
+	if msg := err.Error(); msg != `row 2: stable: cannot convert value of type chan int in column 2 ("status")` {
+		t.Errorf("unexpected message: %s", msg)
+	}
+	if tbl.NumRows() != 1 {
+		t.Errorf("expected 1 row, got %d", tbl.NumRows())
+	}
+
+	// without a header, with the cause
+	tbl = New().StrictNil()
+	err = tbl.AddRow([]interface{}{1, nil})
+	if !errors.As(err, &e) || e.Type != nil {
+		t.Fatalf("expected a ConversionError, got %v", err)
+	}
+	if msg := err.Error(); msg != "stable: cannot convert value of type <nil> in column 2: nil values are not allowed" {
+		t.Errorf("unexpected message: %s", msg)
+	}
+
+	// updating a cell
+	tbl = New()
+	tbl.Header([]string{"id", "status"})
+	tbl.AddRow([]interface{}{1, "ok"})
+	if err = tbl.UpdateCell(0, 1, [][]int{{1}}); !errors.As(err, &e) || !errors.Is(err, errNestedSlice) {
+		t.Errorf("expected a ConversionError, got %v", err)
+	}
+}
+
+func TestHumanizedFloatDigits(t *testing.T) {
+	values := []interface{}{1234.5, 2000.0, 0.125, float32(-7)}
+	for _, humanize := range []bool{true, false} {
+		tbl := New()
+		tbl.HeaderWithFormat([]Column{{Header: "money", Align: AlignRight, Precision: 2, HumanizeNumbers: humanize}})
+		for _, v := range values {
+			tbl.AddRow([]interface{}{v})
+		}
+		expect := "   money\n1,234.50\n2,000.00\n    0.12\n   -7.00\n"
+		if !humanize {
+			expect = "  money\n1234.50\n2000.00\n   0.12\n  -7.00\n"
+		}
+		out := string(tbl.Render(StylePlain))
+		if out != expect {
+			t.Errorf("humanize: %v, unexpected output:\n%s", humanize, out)
+		}
+		// decimal points line up
+		lines := strings.Split(strings.TrimSuffix(out, "\n"), "\n")
+		for _, line := range lines[2:] {
+			if strings.IndexByte(line, '.') != strings.IndexByte(lines[1], '.') {
+				t.Errorf("humanize: %v, decimal points are not aligned:\n%s", humanize, out)
+				break
+			}
+		}
+	}
+}
+
+func TestConvertComplex(t *testing.T) {
+	tbl := New().HumanizeNumbers()
+	tbl.HeaderWithFormat([]Column{{Header: "default"}, {Header: "precision", Precision: 3}})
+	tbl.AddRow([]interface{}{complex(1.5, 2), complex(1234.5, 2)})
+	tbl.AddRow([]interface{}{complex64(complex(-1, -0.25)), complex(1, -1.0/3)})
+	tbl.AddRow([]interface{}{complex(3, 0), complex(0, 0)})
+	expect := [][]string{
+		{"1.5+2i", "1234.500+2.000i"},
+		{"-1-0.25i", "1.000-0.333i"},
+		{"3+0i", "0.000+0.000i"},
+	}
+	if rows := tbl.Rows(); !reflect.DeepEqual(rows, expect) {
+		t.Errorf("expected %q, got %q", expect, rows)
+	}
+
+	tbl = New().OmitZeroImaginary().FloatFormat('f', 3)
+	tbl.Header([]string{"a", "b"})
+	tbl.AddRow([]interface{}{complex(3, 0), complex(3, 0.5)})
+	expect = [][]string{{"3.000", "3.000+0.500i"}}
+	if rows := tbl.Rows(); !reflect.DeepEqual(rows, expect) {
+		t.Errorf("expected %q, got %q", expect, rows)
+	}
+}
+
+func TestConvertJSONNumber(t *testing.T) {
+	tbl := New().HumanizeNumbers()
+	tbl.HeaderWithFormat([]Column{{Header: "int"}, {Header: "float", Precision: 2}, {Header: "large"}, {Header: "huge"}})
+	tbl.AddRow([]interface{}{json.Number("1234567"), json.Number("1234.5"),
+		json.Number("123456789012345678901234567890"), json.Number("1e400")})
+	expect := []string{"1,234,567", "1,234.50", "123,456,789,012,345,678,901,234,567,890", "1e400"}
+	if row := tbl.Rows()[0]; !reflect.DeepEqual(row, expect) {
+		t.Errorf("expected %q, got %q", expect, row)
+	}
+}
+
+func TestConvertMap(t *testing.T) {
+	labels := map[string]string{"tier": "backend", "app": "web", "env": "prod"}
+	attrs := map[string]interface{}{"size": 2048, "ratio": 0.5, "tags": []string{"a", "b"}}
+
+	tbl := New().HumanizeNumbers().MaxWidth(16)
+	tbl.HeaderWithFormat([]Column{{Header: "labels"}, {Header: "attrs", SliceDelimiter: "; "}})
+	for i := 0; i < 3; i++ { // the order of keys is deterministic
+		if err := tbl.AddRow([]interface{}{labels, attrs}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, row := range tbl.Rows() {
+		if row[0] != "app=web, env=prod, tier=backend" || row[1] != "ratio=0.5; size=2,048; tags=a; b" {
+			t.Errorf("unexpected row: %q", row)
+		}
+	}
+	tbl = New().MaxWidth(16)
+	tbl.Header([]string{"labels"})
+	tbl.AddRow([]interface{}{labels})
+	expect := `labels          
+app=web,        
+env=prod,       
+tier=backend    
+`
+	if out := string(tbl.Render(StylePlain)); out != expect {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+
+	// nested maps and maps of other keys
+	for _, v := range []interface{}{map[string]interface{}{"a": map[string]int{"b": 1}}, map[int]int{1: 2}} {
+		if err := tbl.AddRow([]interface{}{v}); err == nil {
+			t.Errorf("%v: expected an error", v)
+		}
+	}
+}
+
+func TestNoHumanizeNumbers(t *testing.T) {
+	tbl := New().HumanizeNumbers()
+	tbl.HeaderWithFormat([]Column{{Header: "id", NoHumanizeNumbers: true}, {Header: "count"},
+		{Header: "both", HumanizeNumbers: true, NoHumanizeNumbers: true}})
+	tbl.AddRow([]interface{}{20230115, 20230115, 1234.5})
+	expect := []string{"20230115", "20,230,115", "1234.5"}
+	if row := tbl.Rows()[0]; !reflect.DeepEqual(row, expect) {
+		t.Errorf("expected %q, got %q", expect, row)
+	}
+}
+
+func TestConvertFunc(t *testing.T) {
+	toMillis := func(v interface{}) (interface{}, error) {
+		ns, ok := v.(int64)
+		if !ok {
+			return nil, fmt.Errorf("expected int64, got %T", v)
+		}
+		return float64(ns) / 1e6, nil
+	}
+	unwrap := func(v interface{}) (interface{}, error) {
+		if s, ok := v.(sql.NullString); ok {
+			if !s.Valid {
+				return nil, nil
+			}
+			return s.String, nil
+		}
+		return v, nil
+	}
+
+	tbl := New()
+	tbl.HeaderWithFormat([]Column{
+		{Header: "ms", ConvertFunc: toMillis, HumanizeNumbers: true, Precision: 1},
+		{Header: "name", ConvertFunc: unwrap},
+	})
+	if err := tbl.AddRow([]interface{}{int64(1234567890), sql.NullString{String: "a", Valid: true}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := tbl.AddRow([]interface{}{int64(2e6), sql.NullString{}}); err != nil {
+		t.Fatal(err)
+	}
+	expect := [][]string{{"1,234.6", "a"}, {"2.0", ""}}
+	if rows := tbl.Rows(); !reflect.DeepEqual(rows, expect) {
+		t.Errorf("expected %q, got %q", expect, rows)
+	}
+
+	err := tbl.AddRow([]interface{}{"1", "b"})
+	var e *ConversionError
+	if !errors.As(err, &e) || e.Column != 0 || e.Value != "1" {
+		t.Fatalf("expected a ConversionError, got %v", err)
+	}
+	if msg := err.Error(); msg != `stable: cannot convert value of type string in column 1 ("ms"): ConvertFunc: expected int64, got string` {
+		t.Errorf("unexpected message: %s", msg)
+	}
+}
+
+func TestParseNumeric(t *testing.T) {
+	numbers := []struct {
+		s      string
+		expect float64
+	}{
+		{"0", 0},
+		{"-12", -12},
+		{" +3.5 ", 3.5},
+		{".5", 0.5},
+		{"1,234", 1234},
+		{"-1,234,567.25", -1234567.25},
+		{"999,999", 999999},
+		{"1.5e-3", 0.0015},
+		{"2E+2", 200},
+		{"12.5%", 12.5},
+		{"-3%", -3},
+	}
+	for _, n := range numbers {
+		if f, ok := ParseNumeric(n.s); !ok || f != n.expect {
+			t.Errorf("%q: expected %v, got %v (%v)", n.s, n.expect, f, ok)
+		}
+	}
+
+	for _, s := range []string{"", "-", ".", "%", "abc", "192.168.0.1", "1.2.3", "v1.2",
+		"1,2,3", "12,34", ",123", "1234,567", "1,234,", "1e", "1e+", "Inf", "NaN", "0x10", "1_000", "5%%", "1e400"} {
+		if f, ok := ParseNumeric(s); ok {
+			t.Errorf("%q: expected not a number, got %v", s, f)
+		}
+	}
+
+	// humanized numbers of a table
+	tbl := New().HumanizeNumbers()
+	tbl.Header([]string{"n"})
+	for _, v := range []interface{}{1234567, -9876.5, uint64(math.MaxUint64)} {
+		tbl.AddRow([]interface{}{v})
+	}
+	for _, row := range tbl.Rows() {
+		if _, ok := ParseNumeric(row[0]); !ok {
+			t.Errorf("%q: expected a number", row[0])
+		}
+	}
+}